@@ -0,0 +1,390 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package audit
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"event_exporter/internal/domain"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	logTypeAudit = "audit"
+	sourceAudit  = "kent-audit"
+
+	// maxBodyBytes bounds one webhook batch.
+	maxBodyBytes = 32 << 20
+)
+
+type Logger interface {
+	Debug(ctx context.Context, msg string, kv ...any)
+	Info(ctx context.Context, msg string, kv ...any)
+	Warn(ctx context.Context, msg string, kv ...any)
+	Error(ctx context.Context, msg string, kv ...any)
+}
+
+type Config struct {
+	Port int
+	Path string
+
+	// TLSCertFile and TLSKeyFile switch the listener to HTTPS, which the
+	// API server webhook backend expects unless told otherwise.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// ClientCAFile requires the API server to present a client certificate
+	// signed by this CA. BearerToken, if set, must be sent in the
+	// Authorization header. Insecure allows a plain HTTP listener.
+	ClientCAFile string
+	BearerToken  string
+	Insecure     bool
+
+	// ClusterID is stamped onto every audit event.
+	ClusterID string
+
+	// Stages, Verbs and Resources are allow-lists; empty allows all.
+	// Resources match "resource" or "resource/subresource". Users and
+	// ExcludeUsers match the username, a trailing "*" matches a prefix.
+	Stages       []string
+	Verbs        []string
+	Resources    []string
+	Users        []string
+	ExcludeUsers []string
+}
+
+// Receiver accepts audit.k8s.io/v1 EventList payloads from the API server
+// audit webhook backend and streams the selected ones as events of log type
+// "audit".
+type Receiver struct {
+	logger       Logger
+	addr         string
+	path         string
+	certFile     string
+	keyFile      string
+	tlsConfig    *tls.Config
+	token        string
+	clusterID    string
+	stages       map[string]struct{}
+	verbs        map[string]struct{}
+	resources    map[string]struct{}
+	users        []string
+	excludeUsers []string
+	ready        atomic.Bool
+}
+
+func NewReceiver(cfg Config, logger Logger) (*Receiver, error) {
+	if cfg.Port <= 0 {
+		return nil, fmt.Errorf("adapters:audit:receiver: port is required")
+	}
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, fmt.Errorf("adapters:audit:receiver: tls cert and key must be set together")
+	}
+	if cfg.TLSCertFile == "" && !cfg.Insecure {
+		return nil, fmt.Errorf("adapters:audit:receiver: tls cert and key are required unless insecure is set")
+	}
+	if cfg.ClientCAFile != "" && cfg.TLSCertFile == "" {
+		return nil, fmt.Errorf("adapters:audit:receiver: client ca file requires tls")
+	}
+	if cfg.Path == "" {
+		cfg.Path = "/audit"
+	}
+
+	var tlsConfig *tls.Config
+	if cfg.TLSCertFile != "" {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("adapters:audit:receiver: failed to read client ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("adapters:audit:receiver: no certificates found in %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	if len(cfg.Stages) == 0 {
+		cfg.Stages = []string{"ResponseComplete", "Panic"}
+	}
+
+	return &Receiver{
+		logger:       logger,
+		addr:         fmt.Sprintf(":%d", cfg.Port),
+		path:         cfg.Path,
+		certFile:     cfg.TLSCertFile,
+		keyFile:      cfg.TLSKeyFile,
+		tlsConfig:    tlsConfig,
+		token:        cfg.BearerToken,
+		clusterID:    cfg.ClusterID,
+		stages:       toSet(cfg.Stages),
+		verbs:        toSet(cfg.Verbs),
+		resources:    toSet(cfg.Resources),
+		users:        cfg.Users,
+		excludeUsers: cfg.ExcludeUsers,
+	}, nil
+}
+
+// Ready reports true while the listener is up.
+func (r *Receiver) Ready() bool {
+	return r.ready.Load()
+}
+
+func (r *Receiver) Stream(ctx context.Context, out chan<- *domain.Event) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc(r.path, r.handler(ctx, out))
+
+	srv := &http.Server{
+		Addr:              r.addr,
+		Handler:           mux,
+		TLSConfig:         r.tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		r.ready.Store(false)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	r.logger.Info(ctx, "adapters:audit:receiver: listening for audit webhooks", "addr", r.addr, "path", r.path, "tls", r.certFile != "",
+		"client_auth", r.tlsConfig != nil && r.tlsConfig.ClientCAs != nil,
+		"token_auth", r.token != "",
+	)
+	r.ready.Store(true)
+
+	var err error
+	if r.certFile != "" {
+		err = srv.ListenAndServeTLS(r.certFile, r.keyFile)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	r.ready.Store(false)
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return ctx.Err()
+	}
+	return fmt.Errorf("adapters:audit:receiver: listener stopped: %w", err)
+}
+
+func (r *Receiver) handler(ctx context.Context, out chan<- *domain.Event) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !r.authorized(req) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var list eventList
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxBodyBytes)).Decode(&list); err != nil {
+			r.logger.Warn(ctx, "adapters:audit:receiver: failed to decode payload", "error", err)
+			http.Error(w, "invalid audit event list", http.StatusBadRequest)
+			return
+		}
+
+		for _, item := range list.Items {
+			if !r.allowed(item) {
+				continue
+			}
+			ev, err := r.toDomain(item)
+			if err != nil {
+				r.logger.Warn(ctx, "adapters:audit:receiver: failed to map audit event", "audit_id", item.AuditID, "error", err)
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			case <-req.Context().Done():
+				return
+			case out <- ev:
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// authorized checks the bearer token; client certificates are verified by
+// the TLS handshake already.
+func (r *Receiver) authorized(req *http.Request) bool {
+	if r.token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(r.token)) == 1
+}
+
+func (r *Receiver) allowed(ev event) bool {
+	if !inSet(r.stages, ev.Stage) || !inSet(r.verbs, ev.Verb) {
+		return false
+	}
+	if len(r.resources) > 0 {
+		if ev.ObjectRef == nil {
+			return false
+		}
+		_, ok := r.resources[ev.ObjectRef.Resource]
+		if !ok && ev.ObjectRef.Subresource != "" {
+			_, ok = r.resources[ev.ObjectRef.Resource+"/"+ev.ObjectRef.Subresource]
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(r.users) > 0 && !matchUser(r.users, ev.User.Username) {
+		return false
+	}
+	return !matchUser(r.excludeUsers, ev.User.Username)
+}
+
+func (r *Receiver) toDomain(ev event) (*domain.Event, error) {
+	ref := ev.ObjectRef
+	if ref == nil {
+		ref = &objectReference{}
+	}
+
+	code := 0
+	if ev.ResponseStatus != nil {
+		code = int(ev.ResponseStatus.Code)
+	}
+
+	eventType := "Normal"
+	if code >= 400 {
+		eventType = "Warning"
+	}
+
+	target := ref.Resource
+	if ref.Subresource != "" {
+		target += "/" + ref.Subresource
+	}
+	if ref.Name != "" {
+		target += " " + ref.Name
+	}
+	if ref.Namespace != "" {
+		target += " in " + ref.Namespace
+	}
+	if target == "" {
+		target = ev.RequestURI
+	}
+	msg := fmt.Sprintf("%s %s %s (%d)", ev.User.Username, ev.Verb, target, code)
+
+	at := ev.StageTimestamp.Time
+	if at.IsZero() {
+		at = ev.RequestReceivedTimestamp.Time
+	}
+
+	apiVersion := ref.APIVersion
+	if ref.APIGroup != "" {
+		apiVersion = ref.APIGroup + "/" + ref.APIVersion
+	}
+
+	out, err := domain.NewEvent(
+		ev.AuditID+"/"+ev.Stage,
+		ref.Name,
+		ref.Namespace,
+		ev.Verb,
+		msg,
+		eventType,
+		// audit events name the resource, not the kind; it is exported as
+		// k8s.resource instead of guessing the kind
+		domain.ObjectRef{
+			Name:       ref.Name,
+			Namespace:  ref.Namespace,
+			UID:        ref.UID,
+			APIVersion: apiVersion,
+		},
+		sourceAudit,
+		at,
+		nil,
+		1,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	out.SetLogType(logTypeAudit)
+	out.SetClusterID(r.clusterID)
+	out.SetAttribute("audit.id", ev.AuditID)
+	out.SetAttribute("audit.stage", ev.Stage)
+	out.SetAttribute("audit.level", ev.Level)
+	out.SetAttribute("audit.verb", ev.Verb)
+	out.SetAttribute("audit.request_uri", ev.RequestURI)
+	out.SetAttribute("audit.user", ev.User.Username)
+	out.SetAttribute("audit.response_code", strconv.Itoa(code))
+	if len(ev.User.Groups) > 0 {
+		out.SetAttribute("audit.groups", strings.Join(ev.User.Groups, ","))
+	}
+	if ev.ImpersonatedUser != nil {
+		out.SetAttribute("audit.impersonated_user", ev.ImpersonatedUser.Username)
+	}
+	if len(ev.SourceIPs) > 0 {
+		out.SetAttribute("audit.source_ips", strings.Join(ev.SourceIPs, ","))
+	}
+	if ev.UserAgent != "" {
+		out.SetAttribute("audit.user_agent", ev.UserAgent)
+	}
+	if ref.Resource != "" {
+		out.SetAttribute("k8s.resource", ref.Resource)
+	}
+	if ref.Subresource != "" {
+		out.SetAttribute("k8s.subresource", ref.Subresource)
+	}
+	if ref.APIGroup != "" {
+		out.SetAttribute("audit.api_group", ref.APIGroup)
+	}
+	if ev.ResponseStatus != nil && ev.ResponseStatus.Reason != "" {
+		out.SetAttribute("audit.response_reason", string(ev.ResponseStatus.Reason))
+	}
+	return out, nil
+}
+
+func toSet(list []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(list))
+	for _, v := range list {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+// inSet treats an empty set as "everything".
+func inSet(set map[string]struct{}, v string) bool {
+	if len(set) == 0 {
+		return true
+	}
+	_, ok := set[v]
+	return ok
+}
+
+func matchUser(patterns []string, user string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(user, prefix) {
+				return true
+			}
+		} else if p == user {
+			return true
+		}
+	}
+	return false
+}
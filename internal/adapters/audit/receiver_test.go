@@ -0,0 +1,156 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package audit
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(context.Context, string, ...any) {}
+func (nopLogger) Info(context.Context, string, ...any)  {}
+func (nopLogger) Warn(context.Context, string, ...any)  {}
+func (nopLogger) Error(context.Context, string, ...any) {}
+
+func TestReceiverAllowed(t *testing.T) {
+	ev := func(verb, user, resource, subresource string) event {
+		e := event{Stage: "ResponseComplete", Verb: verb, User: userInfo{Username: user}}
+		if resource != "" {
+			e.ObjectRef = &objectReference{Resource: resource, Subresource: subresource}
+		}
+		return e
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		ev   event
+		want bool
+	}{
+		{"defaults allow all", Config{}, ev("get", "alice", "pods", ""), true},
+		{"default stages", Config{}, event{Stage: "RequestReceived", Verb: "get"}, false},
+		{"verb allowed", Config{Verbs: []string{"delete"}}, ev("delete", "alice", "pods", ""), true},
+		{"verb denied", Config{Verbs: []string{"delete"}}, ev("get", "alice", "pods", ""), false},
+		{"resource allowed", Config{Resources: []string{"secrets"}}, ev("get", "alice", "secrets", ""), true},
+		{"resource denied", Config{Resources: []string{"secrets"}}, ev("get", "alice", "pods", ""), false},
+		{"resource without object", Config{Resources: []string{"secrets"}}, ev("get", "alice", "", ""), false},
+		{"subresource", Config{Resources: []string{"pods/exec"}}, ev("create", "alice", "pods", "exec"), true},
+		{"other subresource", Config{Resources: []string{"pods/exec"}}, ev("create", "alice", "pods", "log"), false},
+		{"resource covers subresources", Config{Resources: []string{"pods"}}, ev("create", "alice", "pods", "exec"), true},
+		{"user allowed", Config{Users: []string{"alice"}}, ev("get", "alice", "pods", ""), true},
+		{"user denied", Config{Users: []string{"alice"}}, ev("get", "bob", "pods", ""), false},
+		{"user prefix", Config{Users: []string{"oidc:*"}}, ev("get", "oidc:alice", "pods", ""), true},
+		{"excluded user", Config{ExcludeUsers: []string{"system:*"}}, ev("get", "system:kube-scheduler", "pods", ""), false},
+		{"exclude wins", Config{Users: []string{"system:*"}, ExcludeUsers: []string{"system:node:*"}}, ev("get", "system:node:n1", "pods", ""), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Port = 8443
+			tt.cfg.Insecure = true
+			r, err := NewReceiver(tt.cfg, nopLogger{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.allowed(tt.ev); got != tt.want {
+				t.Fatalf("allowed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewReceiverRequiresTLS(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"no tls", Config{Port: 8443}, true},
+		{"insecure", Config{Port: 8443, Insecure: true}, false},
+		{"client ca without tls", Config{Port: 8443, Insecure: true, ClientCAFile: "ca.crt"}, true},
+		{"cert without key", Config{Port: 8443, TLSCertFile: "tls.crt"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReceiver(tt.cfg, nopLogger{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReceiverAuthorized(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   bool
+	}{
+		{"no token configured", "", "", true},
+		{"missing header", "s3cret", "", false},
+		{"wrong token", "s3cret", "Bearer nope", false},
+		{"wrong scheme", "s3cret", "Basic s3cret", false},
+		{"valid token", "s3cret", "Bearer s3cret", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewReceiver(Config{Port: 8443, Insecure: true, BearerToken: tt.token}, nopLogger{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest("POST", "/audit", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if got := r.authorized(req); got != tt.want {
+				t.Fatalf("authorized = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReceiverToDomainResource(t *testing.T) {
+	r, err := NewReceiver(Config{Port: 8443, Insecure: true}, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ev, err := r.toDomain(event{
+		AuditID:        "id",
+		Stage:          "ResponseComplete",
+		Verb:           "create",
+		User:           userInfo{Username: "alice"},
+		StageTimestamp: metav1.NewMicroTime(time.Now()),
+		ObjectRef: &objectReference{
+			Resource:    "pods",
+			Subresource: "exec",
+			Namespace:   "default",
+			Name:        "web-0",
+			APIVersion:  "v1",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if kind := ev.Object().Kind; kind != "" {
+		t.Fatalf("kind = %q, want empty", kind)
+	}
+	attrs := ev.Attributes()
+	if attrs["k8s.resource"] != "pods" || attrs["k8s.subresource"] != "exec" {
+		t.Fatalf("resource attributes = %q, %q", attrs["k8s.resource"], attrs["k8s.subresource"])
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// ConfigMapStore keeps positions as data keys of a ConfigMap.
+type ConfigMapStore struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+}
+
+func NewConfigMapStore(client kubernetes.Interface, namespace, name string) (*ConfigMapStore, error) {
+	if namespace == "" || name == "" {
+		return nil, fmt.Errorf("adapters:checkpoint:configmap: namespace and name are required")
+	}
+	return &ConfigMapStore{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+	}, nil
+}
+
+func (s *ConfigMapStore) Load(ctx context.Context) (map[string]string, error) {
+	positions := make(map[string]string)
+
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return positions, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("adapters:checkpoint:configmap: failed to get configmap: %w", err)
+	}
+
+	for k, v := range cm.Data {
+		positions[k] = v
+	}
+	return positions, nil
+}
+
+func (s *ConfigMapStore) Save(ctx context.Context, positions map[string]string) error {
+	err := retry.OnError(retry.DefaultRetry, retriable, func() error {
+		cms := s.client.CoreV1().ConfigMaps(s.namespace)
+
+		cm, err := cms.Get(ctx, s.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			_, err = cms.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: s.namespace},
+				Data:       positions,
+			}, metav1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		if cm.Data == nil {
+			cm.Data = make(map[string]string, len(positions))
+		}
+		for k, v := range positions {
+			cm.Data[k] = v
+		}
+
+		_, err = cms.Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("adapters:checkpoint:configmap: failed to save positions: %w", err)
+	}
+	return nil
+}
+
+// retriable repeats the get/update cycle on update conflicts and when
+// another replica created the object between our Get and Create.
+func retriable(err error) bool {
+	return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore keeps positions as a JSON object in a local file, typically on a
+// persistent volume.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileStore(path string) (*FileStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("adapters:checkpoint:file: path is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("adapters:checkpoint:file: cannot create directory: %w", err)
+	}
+	return &FileStore{path: path}, nil
+}
+
+func (s *FileStore) Load(_ context.Context) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read()
+}
+
+func (s *FileStore) Save(_ context.Context, positions map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.read()
+	if err != nil {
+		return err
+	}
+	for k, v := range positions {
+		current[k] = v
+	}
+
+	data, err := json.Marshal(current)
+	if err != nil {
+		return fmt.Errorf("adapters:checkpoint:file: failed to encode positions: %w", err)
+	}
+
+	// write to a temp file first so a crash never leaves a truncated checkpoint
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("adapters:checkpoint:file: failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("adapters:checkpoint:file: failed to replace checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) read() (map[string]string, error) {
+	positions := make(map[string]string)
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return positions, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("adapters:checkpoint:file: failed to read checkpoint: %w", err)
+	}
+	if len(data) == 0 {
+		return positions, nil
+	}
+
+	if err := json.Unmarshal(data, &positions); err != nil {
+		return nil, fmt.Errorf("adapters:checkpoint:file: failed to decode checkpoint: %w", err)
+	}
+	return positions, nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package checkpoint
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// leaseAnnotationPrefix is prepended to every position key. The "rv-" part
+// keeps keys such as "_all" valid annotation names.
+const (
+	leaseAnnotationDomain = "checkpoint.kent.io/"
+	leaseAnnotationPrefix = leaseAnnotationDomain + "rv-"
+)
+
+// leaseHashedPrefix marks keys too long for the 63 character name part of
+// an annotation. Their name is a truncated key plus a hash of the full key,
+// and the value carries the full key as "<key>=<position>".
+const (
+	leaseHashedPrefix = leaseAnnotationDomain + "rvh-"
+	maxAnnotationName = 63
+)
+
+// leaseAnnotation returns the annotation name and value for a position.
+func leaseAnnotation(key, position string) (string, string) {
+	if len(leaseAnnotationPrefix)-len(leaseAnnotationDomain)+len(key) <= maxAnnotationName {
+		return leaseAnnotationPrefix + key, position
+	}
+	sum := sha256.Sum256([]byte(key))
+	hash := hex.EncodeToString(sum[:8])
+	keep := maxAnnotationName - (len(leaseHashedPrefix) - len(leaseAnnotationDomain)) - len(hash) - 1
+	return leaseHashedPrefix + key[:keep] + "-" + hash, key + "=" + position
+}
+
+// leasePosition reverses leaseAnnotation. ok is false for annotations that
+// do not belong to the store.
+func leasePosition(name, value string) (key, position string, ok bool) {
+	if strings.HasPrefix(name, leaseHashedPrefix) {
+		i := strings.LastIndex(value, "=")
+		if i < 0 {
+			return "", "", false
+		}
+		return value[:i], value[i+1:], true
+	}
+	key, ok = strings.CutPrefix(name, leaseAnnotationPrefix)
+	return key, value, ok
+}
+
+// LeaseStore keeps positions as annotations of a coordination.k8s.io Lease.
+type LeaseStore struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+}
+
+func NewLeaseStore(client kubernetes.Interface, namespace, name string) (*LeaseStore, error) {
+	if namespace == "" || name == "" {
+		return nil, fmt.Errorf("adapters:checkpoint:lease: namespace and name are required")
+	}
+	return &LeaseStore{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+	}, nil
+}
+
+func (s *LeaseStore) Load(ctx context.Context) (map[string]string, error) {
+	positions := make(map[string]string)
+
+	lease, err := s.client.CoordinationV1().Leases(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return positions, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("adapters:checkpoint:lease: failed to get lease: %w", err)
+	}
+
+	for k, v := range lease.Annotations {
+		if key, position, ok := leasePosition(k, v); ok {
+			positions[key] = position
+		}
+	}
+	return positions, nil
+}
+
+func (s *LeaseStore) Save(ctx context.Context, positions map[string]string) error {
+	annotations := make(map[string]string, len(positions))
+	for k, v := range positions {
+		name, value := leaseAnnotation(k, v)
+		annotations[name] = value
+	}
+
+	err := retry.OnError(retry.DefaultRetry, retriable, func() error {
+		leases := s.client.CoordinationV1().Leases(s.namespace)
+
+		lease, err := leases.Get(ctx, s.name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			_, err = leases.Create(ctx, &coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        s.name,
+					Namespace:   s.namespace,
+					Annotations: annotations,
+				},
+			}, metav1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		if lease.Annotations == nil {
+			lease.Annotations = make(map[string]string, len(annotations))
+		}
+		for k, v := range annotations {
+			lease.Annotations[k] = v
+		}
+
+		_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("adapters:checkpoint:lease: failed to save positions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package checkpoint
+
+import (
+	"context"
+	"maps"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type store interface {
+	Load(ctx context.Context) (map[string]string, error)
+	Save(ctx context.Context, positions map[string]string) error
+}
+
+func TestStoreMerge(t *testing.T) {
+	stores := map[string]func(t *testing.T) store{
+		"file": func(t *testing.T) store {
+			s, err := NewFileStore(filepath.Join(t.TempDir(), "state", "checkpoint.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+		"configmap": func(t *testing.T) store {
+			s, err := NewConfigMapStore(fake.NewClientset(), "monitoring", "kent-checkpoint")
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+		"lease": func(t *testing.T) store {
+			s, err := NewLeaseStore(fake.NewClientset(), "monitoring", "kent-checkpoint")
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+	}
+
+	tests := []struct {
+		name  string
+		saves []map[string]string
+		want  map[string]string
+	}{
+		{"empty store", nil, map[string]string{}},
+		{"single save", []map[string]string{{"_all": "100"}}, map[string]string{"_all": "100"}},
+		{
+			"keys of other watches are kept",
+			[]map[string]string{{"apps": "100", "web": "200"}, {"web": "250", "prod._all": "7"}},
+			map[string]string{"apps": "100", "web": "250", "prod._all": "7"},
+		},
+		{
+			"later save wins",
+			[]map[string]string{{"apps": "100"}, {"apps": "150"}, {"apps": "175"}},
+			map[string]string{"apps": "175"},
+		},
+	}
+
+	for name, newStore := range stores {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				s := newStore(t)
+				for _, positions := range tt.saves {
+					if err := s.Save(ctx, positions); err != nil {
+						t.Fatal(err)
+					}
+				}
+				got, err := s.Load(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !maps.Equal(got, tt.want) {
+					t.Fatalf("positions = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestLeaseStoreKeepsForeignAnnotations(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset(&coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "kent-checkpoint",
+			Namespace:   "monitoring",
+			Annotations: map[string]string{"owner": "platform"},
+		},
+	})
+
+	s, err := NewLeaseStore(client, "monitoring", "kent-checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(ctx, map[string]string{"_all": "42"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"_all": "42"}; !maps.Equal(got, want) {
+		t.Fatalf("positions = %v, want %v", got, want)
+	}
+
+	lease, err := client.CoordinationV1().Leases("monitoring").Get(ctx, "kent-checkpoint", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease.Annotations["owner"] != "platform" {
+		t.Fatalf("foreign annotation lost: %v", lease.Annotations)
+	}
+}
+
+func TestLeaseStoreLongKeys(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	s, err := NewLeaseStore(client, "monitoring", "kent-checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	long := "production-eu-west-1." + strings.Repeat("n", 63)
+	want := map[string]string{"_all": "42", long: "43", long + "x": "44"}
+	if err := s.Save(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+
+	lease, err := client.CoordinationV1().Leases("monitoring").Get(ctx, "kent-checkpoint", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name := range lease.Annotations {
+		if errs := validation.IsQualifiedName(name); len(errs) > 0 {
+			t.Errorf("annotation %q is invalid: %v", name, errs)
+		}
+	}
+
+	got, err := s.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(got, want) {
+		t.Fatalf("positions = %v, want %v", got, want)
+	}
+}
+
+func TestStoreSaveRetriesAfterConcurrentCreate(t *testing.T) {
+	stores := map[string]struct {
+		resource string
+		object   runtime.Object
+		open     func(client *fake.Clientset) (store, error)
+	}{
+		"configmap": {
+			resource: "configmaps",
+			object: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "kent-checkpoint", Namespace: "monitoring"},
+				Data:       map[string]string{"other": "7"},
+			},
+			open: func(client *fake.Clientset) (store, error) {
+				return NewConfigMapStore(client, "monitoring", "kent-checkpoint")
+			},
+		},
+		"lease": {
+			resource: "leases",
+			object: &coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "kent-checkpoint",
+					Namespace:   "monitoring",
+					Annotations: map[string]string{leaseAnnotationPrefix + "other": "7"},
+				},
+			},
+			open: func(client *fake.Clientset) (store, error) {
+				return NewLeaseStore(client, "monitoring", "kent-checkpoint")
+			},
+		},
+	}
+
+	for name, tt := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			client := fake.NewClientset(tt.object)
+			// The first Get misses the object another replica has just
+			// created, so Create fails with AlreadyExists.
+			missed := false
+			client.PrependReactor("get", tt.resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if missed {
+					return false, nil, nil
+				}
+				missed = true
+				return true, nil, apierrors.NewNotFound(schema.GroupResource{Resource: tt.resource}, "kent-checkpoint")
+			})
+
+			s, err := tt.open(client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Save(ctx, map[string]string{"_all": "42"}); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := s.Load(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := map[string]string{"_all": "42", "other": "7"}; !maps.Equal(got, want) {
+				t.Fatalf("positions = %v, want %v", got, want)
+			}
+		})
+	}
+}
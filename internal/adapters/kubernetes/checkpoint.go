@@ -0,0 +1,167 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// clusterScopeKey is the checkpoint key of a watch that is not bound to a
+// single namespace.
+const clusterScopeKey = "_all"
+
+// CheckpointStore persists watch positions (resourceVersions) keyed by stream.
+// Save merges the given keys into what is already stored.
+type CheckpointStore interface {
+	Load(ctx context.Context) (map[string]string, error)
+	Save(ctx context.Context, positions map[string]string) error
+}
+
+// checkpointer collects positions confirmed by the writers and periodically
+// hands them to the store. A nil checkpointer ignores all calls.
+//
+// Positions are committed in watch order: a position only becomes pending
+// once everything tracked before it on the same watch was delivered or
+// skipped. Relisted items arrive in key order, so they carry no position of
+// their own and the list resourceVersion is committed after them.
+type checkpointer struct {
+	store    CheckpointStore
+	interval time.Duration
+	logger   Logger
+
+	mu      sync.Mutex
+	pending map[string]string
+	marks   map[string][]*mark
+}
+
+// mark is one tracked step of a watch; rv is empty for relisted items.
+type mark struct {
+	rv   string
+	done bool
+}
+
+func newCheckpointer(store CheckpointStore, interval time.Duration, logger Logger) *checkpointer {
+	if store == nil {
+		return nil
+	}
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	return &checkpointer{
+		store:    store,
+		interval: interval,
+		logger:   logger,
+		pending:  make(map[string]string),
+		marks:    make(map[string][]*mark),
+	}
+}
+
+// track registers a forwarded event at position rv of the watch key and
+// returns the hook to call once it is delivered or dropped on purpose.
+func (c *checkpointer) track(key, rv string) func() {
+	if c == nil {
+		return func() {}
+	}
+
+	m := &mark{rv: rv}
+	c.mu.Lock()
+	c.marks[key] = append(c.marks[key], m)
+	c.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			m.done = true
+			c.commitLocked(key)
+			c.mu.Unlock()
+		})
+	}
+}
+
+// advance moves the watch key to rv once everything tracked before it is
+// delivered; used for bookmarks, the end of a relist and skipped events.
+func (c *checkpointer) advance(key, rv string) {
+	if c == nil || rv == "" {
+		return
+	}
+
+	c.mu.Lock()
+	c.marks[key] = append(c.marks[key], &mark{rv: rv, done: true})
+	c.commitLocked(key)
+	c.mu.Unlock()
+}
+
+func (c *checkpointer) commitLocked(key string) {
+	marks := c.marks[key]
+	n := 0
+	for n < len(marks) && marks[n].done {
+		if marks[n].rv != "" {
+			c.pending[key] = marks[n].rv
+		}
+		n++
+	}
+	if n == len(marks) {
+		delete(c.marks, key)
+		return
+	}
+	c.marks[key] = marks[n:]
+}
+
+func (c *checkpointer) run(ctx context.Context) {
+	if c == nil {
+		return
+	}
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			c.flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			c.flush(ctx)
+		}
+	}
+}
+
+func (c *checkpointer) flush(ctx context.Context) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	if len(c.pending) == 0 {
+		c.mu.Unlock()
+		return
+	}
+	positions := c.pending
+	c.pending = make(map[string]string)
+	c.mu.Unlock()
+
+	if err := c.store.Save(ctx, positions); err != nil {
+		c.logger.Error(ctx, "adapters:kubernetes:checkpoint: failed to save positions", "error", err)
+
+		// keep them for the next attempt unless newer ones arrived meanwhile
+		c.mu.Lock()
+		for k, v := range positions {
+			if _, ok := c.pending[k]; !ok {
+				c.pending[k] = v
+			}
+		}
+		c.mu.Unlock()
+		return
+	}
+
+	c.logger.Debug(ctx, "adapters:kubernetes:checkpoint: positions saved", "positions", positions)
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(context.Context, string, ...any) {}
+func (nopLogger) Info(context.Context, string, ...any)  {}
+func (nopLogger) Warn(context.Context, string, ...any)  {}
+func (nopLogger) Error(context.Context, string, ...any) {}
+
+type memStore struct{ saved map[string]string }
+
+func (s *memStore) Load(context.Context) (map[string]string, error) { return s.saved, nil }
+func (s *memStore) Save(_ context.Context, positions map[string]string) error {
+	if s.saved == nil {
+		s.saved = make(map[string]string)
+	}
+	for k, v := range positions {
+		s.saved[k] = v
+	}
+	return nil
+}
+
+func TestCheckpointerCommitsInWatchOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(c *checkpointer)
+		want string
+	}{
+		{
+			name: "watch events delivered in order",
+			run: func(c *checkpointer) {
+				a, b := c.track("k", "10"), c.track("k", "11")
+				a()
+				b()
+			},
+			want: "11",
+		},
+		{
+			name: "later delivery waits for an earlier one",
+			run: func(c *checkpointer) {
+				_ = c.track("k", "10")
+				b := c.track("k", "11")
+				b()
+			},
+			want: "",
+		},
+		{
+			name: "relist commits the list version after all items",
+			run: func(c *checkpointer) {
+				x, y := c.track("k", ""), c.track("k", "")
+				c.advance("k", "50")
+				y()
+				x()
+			},
+			want: "50",
+		},
+		{
+			name: "relist with an undelivered item keeps the old position",
+			run: func(c *checkpointer) {
+				c.advance("k", "5")
+				x := c.track("k", "")
+				_ = c.track("k", "")
+				c.advance("k", "50")
+				x()
+			},
+			want: "5",
+		},
+		{
+			name: "bookmark after delivered events",
+			run: func(c *checkpointer) {
+				a := c.track("k", "10")
+				c.advance("k", "20")
+				a()
+			},
+			want: "20",
+		},
+		{
+			name: "hook is idempotent",
+			run: func(c *checkpointer) {
+				a := c.track("k", "10")
+				a()
+				_ = c.track("k", "11")
+				a()
+			},
+			want: "10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memStore{}
+			c := newCheckpointer(store, 0, nopLogger{})
+			tt.run(c)
+			c.flush(context.Background())
+			if got := store.saved["k"]; got != tt.want {
+				t.Fatalf("position = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNilCheckpointer(t *testing.T) {
+	var c *checkpointer
+	c.track("k", "1")()
+	c.advance("k", "2")
+	c.flush(context.Background())
+}
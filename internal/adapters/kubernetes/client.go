@@ -0,0 +1,77 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ClientConfig describes how to reach the API server.
+type ClientConfig struct {
+	Kubeconfig string
+	Context    string
+
+	// Server, TokenFile and CAFile describe the connection without a
+	// kubeconfig. The token file is re-read by client-go on rotation.
+	Server    string
+	TokenFile string
+	CAFile    string
+
+	QPS   float32
+	Burst int
+}
+
+// NewRestConfig resolves the API server connection. An explicit server or
+// kubeconfig/context wins; otherwise the in-cluster service account is tried
+// first, then $KUBECONFIG and ~/.kube/config. The second value names the
+// source used.
+func NewRestConfig(cfg ClientConfig) (*rest.Config, string, error) {
+	var (
+		restCfg *rest.Config
+		source  string
+	)
+
+	if cfg.Server != "" {
+		restCfg, source = &rest.Config{
+			Host:            cfg.Server,
+			BearerTokenFile: cfg.TokenFile,
+			TLSClientConfig: rest.TLSClientConfig{CAFile: cfg.CAFile},
+		}, "server"
+	}
+
+	if restCfg == nil && cfg.Kubeconfig == "" && cfg.Context == "" {
+		if c, err := rest.InClusterConfig(); err == nil {
+			restCfg, source = c, "in-cluster"
+		}
+	}
+
+	if restCfg == nil {
+		rules := clientcmd.NewDefaultClientConfigLoadingRules()
+		if cfg.Kubeconfig != "" {
+			rules.ExplicitPath = cfg.Kubeconfig
+		}
+		overrides := &clientcmd.ConfigOverrides{CurrentContext: cfg.Context}
+
+		c, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+		if err != nil {
+			return nil, "", fmt.Errorf("adapters:kubernetes:client: no in-cluster config and no usable kubeconfig: %w", err)
+		}
+		restCfg, source = c, "kubeconfig"
+	}
+
+	if cfg.QPS > 0 {
+		restCfg.QPS = cfg.QPS
+	}
+	if cfg.Burst > 0 {
+		restCfg.Burst = cfg.Burst
+	}
+
+	return restCfg, source, nil
+}
@@ -0,0 +1,344 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"event_exporter/internal/domain"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	logTypeCondition = "condition"
+	sourceCondition  = "kent-conditions"
+
+	// rediscoveryInterval is how often resources that were not served at
+	// startup, e.g. CRDs installed after KENT, are looked up again.
+	rediscoveryInterval = time.Minute
+)
+
+// negativeConditions are condition types for which "True" is the bad state.
+var negativeConditions = map[string]struct{}{
+	"Stalled":  {},
+	"Degraded": {},
+	"Failed":   {},
+	"Error":    {},
+}
+
+// ConditionFetcher watches arbitrary resources through the dynamic client
+// and emits an event of log type "condition" whenever one of their
+// .status.conditions changes status or reason.
+type ConditionFetcher struct {
+	client     dynamic.Interface
+	discovery  discovery.DiscoveryInterface
+	logger     Logger
+	resync     time.Duration
+	clusterID  string
+	resources  []schema.GroupVersionResource
+	namespaces []string
+	includeNS  map[string]struct{}
+	excludeNS  map[string]struct{}
+	nsFilter   *NamespaceFilter
+	ready      atomic.Bool
+}
+
+func NewConditionFetcher(
+	logger Logger,
+	cfg FetcherConfig,
+	resources []schema.GroupVersionResource,
+	resync time.Duration,
+	client dynamic.Interface,
+	dc discovery.DiscoveryInterface,
+) *ConditionFetcher {
+	return &ConditionFetcher{
+		client:     client,
+		discovery:  dc,
+		logger:     logger,
+		resync:     resync,
+		clusterID:  cfg.ClusterID,
+		resources:  resources,
+		namespaces: watchNamespaces(cfg.IncludeNamespaces),
+		includeNS:  toSet(cfg.IncludeNamespaces),
+		excludeNS:  toSet(cfg.ExcludeNamespaces),
+		nsFilter:   cfg.NamespaceFilter,
+	}
+}
+
+// Ready reports true once the caches of all served resources synced.
+func (f *ConditionFetcher) Ready() bool {
+	return f.ready.Load()
+}
+
+func (f *ConditionFetcher) Stream(ctx context.Context, out chan<- *domain.Event) error {
+	defer f.ready.Store(false)
+
+	handlers := cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj any) {
+			oldU, ok1 := oldObj.(*unstructured.Unstructured)
+			newU, ok2 := newObj.(*unstructured.Unstructured)
+			if !ok1 || !ok2 || oldU.GetResourceVersion() == newU.GetResourceVersion() {
+				return
+			}
+			for _, ev := range f.transitions(ctx, oldU, newU) {
+				select {
+				case <-ctx.Done():
+					return
+				case out <- ev:
+				}
+			}
+		},
+	}
+
+	var synced []cache.InformerSynced
+	var unserved []schema.GroupVersionResource
+	for _, gvr := range f.resources {
+		namespaced, err := f.namespaced(gvr)
+		if err != nil {
+			// the CRD may be installed later; discovery is re-checked below
+			f.logger.Warn(ctx, "adapters:kubernetes:conditions: resource is not served; retrying later",
+				"cluster", f.clusterID,
+				"resource", gvr.String(),
+				"error", err,
+			)
+			unserved = append(unserved, gvr)
+			continue
+		}
+
+		informers, shutdown, err := f.watch(ctx, gvr, namespaced, handlers)
+		if err != nil {
+			return err
+		}
+		defer shutdown()
+		synced = append(synced, informers...)
+	}
+
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		return ctx.Err()
+	}
+	f.ready.Store(true)
+	f.logger.Info(ctx, "adapters:kubernetes:conditions: caches synced", "cluster", f.clusterID, "informers", len(synced))
+
+	ticker := time.NewTicker(rediscoveryInterval)
+	defer ticker.Stop()
+	for len(unserved) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		pending := unserved[:0]
+		for _, gvr := range unserved {
+			namespaced, err := f.namespaced(gvr)
+			if err != nil {
+				pending = append(pending, gvr)
+				continue
+			}
+			_, shutdown, err := f.watch(ctx, gvr, namespaced, handlers)
+			if err != nil {
+				return err
+			}
+			defer shutdown()
+			f.logger.Info(ctx, "adapters:kubernetes:conditions: resource is served now; watching",
+				"cluster", f.clusterID,
+				"resource", gvr.String(),
+			)
+		}
+		unserved = pending
+	}
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// watch starts informers for gvr, one per watched namespace when the
+// resource is namespaced, and returns their sync functions.
+func (f *ConditionFetcher) watch(
+	ctx context.Context,
+	gvr schema.GroupVersionResource,
+	namespaced bool,
+	handlers cache.ResourceEventHandler,
+) ([]cache.InformerSynced, func(), error) {
+	scopes := []string{metav1.NamespaceAll}
+	if namespaced {
+		scopes = f.namespaces
+	}
+
+	var synced []cache.InformerSynced
+	var factories []dynamicinformer.DynamicSharedInformerFactory
+	shutdown := func() {
+		for _, factory := range factories {
+			factory.Shutdown()
+		}
+	}
+	for _, ns := range scopes {
+		factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(f.client, f.resync, ns, nil)
+		informer := factory.ForResource(gvr).Informer()
+		if _, err := informer.AddEventHandler(handlers); err != nil {
+			shutdown()
+			return nil, nil, fmt.Errorf("adapters:kubernetes:conditions: failed to register handler: %w", err)
+		}
+		factory.Start(ctx.Done())
+		factories = append(factories, factory)
+		synced = append(synced, informer.HasSynced)
+	}
+	return synced, shutdown, nil
+}
+
+func (f *ConditionFetcher) namespaced(gvr schema.GroupVersionResource) (bool, error) {
+	list, err := f.discovery.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if err != nil {
+		return false, err
+	}
+	for _, r := range list.APIResources {
+		if r.Name == gvr.Resource {
+			return r.Namespaced, nil
+		}
+	}
+	return false, fmt.Errorf("resource %q not found in %s", gvr.Resource, list.GroupVersion)
+}
+
+// resourceCondition is the part of a metav1.Condition-like entry KENT reads.
+type resourceCondition struct {
+	Type               string
+	Status             string
+	Reason             string
+	Message            string
+	LastTransitionTime time.Time
+}
+
+func conditionsOf(obj *unstructured.Unstructured) map[string]resourceCondition {
+	items, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if !found || err != nil {
+		return nil
+	}
+
+	conditions := make(map[string]resourceCondition, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		c := resourceCondition{}
+		c.Type, _, _ = unstructured.NestedString(m, "type")
+		c.Status, _, _ = unstructured.NestedString(m, "status")
+		c.Reason, _, _ = unstructured.NestedString(m, "reason")
+		c.Message, _, _ = unstructured.NestedString(m, "message")
+		if ts, _, _ := unstructured.NestedString(m, "lastTransitionTime"); ts != "" {
+			if t, err := time.Parse(time.RFC3339, ts); err == nil {
+				c.LastTransitionTime = t
+			}
+		}
+		if c.Type != "" {
+			conditions[c.Type] = c
+		}
+	}
+	return conditions
+}
+
+func (f *ConditionFetcher) transitions(ctx context.Context, oldObj, newObj *unstructured.Unstructured) []*domain.Event {
+	ns := newObj.GetNamespace()
+	if ns != "" && (!namespaceAllowed(f.includeNS, f.excludeNS, ns) || !f.nsFilter.Allowed(ns)) {
+		return nil
+	}
+
+	previous := conditionsOf(oldObj)
+
+	var events []*domain.Event
+	for condType, c := range conditionsOf(newObj) {
+		prev, ok := previous[condType]
+		if ok && prev.Status == c.Status && prev.Reason == c.Reason {
+			continue
+		}
+
+		ev, err := f.newEvent(newObj, c)
+		if err != nil {
+			f.logger.Warn(ctx, "adapters:kubernetes:conditions: failed to build event",
+				"kind", newObj.GetKind(),
+				"name", newObj.GetName(),
+				"error", err,
+			)
+			continue
+		}
+		if ok {
+			ev.SetAttribute("condition.previous_status", prev.Status)
+			if prev.Reason != "" {
+				ev.SetAttribute("condition.previous_reason", prev.Reason)
+			}
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+func (f *ConditionFetcher) newEvent(obj *unstructured.Unstructured, c resourceCondition) (*domain.Event, error) {
+	eventType := corev1.EventTypeNormal
+	_, negative := negativeConditions[c.Type]
+	if (negative && c.Status == string(metav1.ConditionTrue)) || (!negative && c.Status == string(metav1.ConditionFalse)) {
+		eventType = corev1.EventTypeWarning
+	}
+
+	at := c.LastTransitionTime
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+
+	msg := fmt.Sprintf("%s %s condition %s is %s", obj.GetKind(), obj.GetName(), c.Type, c.Status)
+	if c.Message != "" {
+		msg += ": " + c.Message
+	}
+
+	reason := c.Reason
+	if reason == "" {
+		reason = c.Type
+	}
+
+	uid := fmt.Sprintf("%s/%s/%s/%s/%d", obj.GetUID(), c.Type, c.Status, c.Reason, at.Unix())
+
+	ev, err := domain.NewEvent(
+		uid,
+		obj.GetName(),
+		obj.GetNamespace(),
+		reason,
+		msg,
+		eventType,
+		domain.ObjectRef{
+			Kind:       obj.GetKind(),
+			Name:       obj.GetName(),
+			Namespace:  obj.GetNamespace(),
+			UID:        string(obj.GetUID()),
+			APIVersion: obj.GetAPIVersion(),
+		},
+		sourceCondition,
+		at,
+		nil,
+		1,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ev.SetLogType(logTypeCondition)
+	ev.SetClusterID(f.clusterID)
+	ev.SetAttribute("condition.type", c.Type)
+	ev.SetAttribute("condition.status", c.Status)
+	if c.Reason != "" {
+		ev.SetAttribute("condition.reason", c.Reason)
+	}
+	return ev, nil
+}
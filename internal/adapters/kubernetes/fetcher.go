@@ -9,11 +9,15 @@ package kubernetes
 import (
 	"context"
 	"event_exporter/internal/domain"
+	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -24,116 +28,347 @@ type Logger interface {
 	Error(ctx context.Context, msg string, kv ...any)
 }
 
+// FetcherConfig is shared by Fetcher, FetcherV1 and InformerFetcher.
+type FetcherConfig struct {
+	// ClusterID is stamped onto every event.
+	ClusterID string
+
+	// IncludeNamespaces opens one watch per namespace, so KENT only needs
+	// namespaced Roles. Empty means a single cluster-wide watch.
+	IncludeNamespaces []string
+	ExcludeNamespaces []string
+	FieldSelectors    EventFieldSelectors
+
+	// NamespaceFilter, when set, additionally matches namespaces by label.
+	NamespaceFilter *NamespaceFilter
+
+	// ExportDeleted forwards DELETED notifications (usually TTL expiry of
+	// an event) instead of dropping them.
+	ExportDeleted bool
+
+	// Checkpoint, when set, receives the watch position once the writers
+	// confirmed every event before it. Positions are the ones loaded at
+	// startup.
+	Checkpoint         CheckpointStore
+	CheckpointInterval time.Duration
+	Positions          map[string]string
+}
+
+// watchState is the resume point of one watch (a namespace or the cluster).
+type watchState struct {
+	namespace string
+	key       string
+
+	// resourceVersion is the position the next watch resumes from.
+	// Empty means a fresh list is required.
+	resourceVersion string
+	// seen maps event UID to the last resourceVersion forwarded, so a relist
+	// after "410 Gone" only emits events that changed while we were away.
+	seen map[types.UID]string
+}
+
+func newWatchState(clusterID, namespace string, positions map[string]string) *watchState {
+	key := checkpointKey(clusterID, namespace)
+	return &watchState{
+		namespace:       namespace,
+		key:             key,
+		resourceVersion: positions[key],
+		seen:            make(map[types.UID]string),
+	}
+}
+
 type Fetcher struct {
-	client    *kubernetes.Clientset
+	client    kubernetes.Interface
 	logger    Logger
 	includeNS map[string]struct{}
 	excludeNS map[string]struct{}
+	nsFilter  *NamespaceFilter
 	ready     atomic.Bool
+
+	clusterID     string
+	namespaces    []string
+	fieldSelector map[string]string
+	exportDeleted bool
+	checkpoint    *checkpointer
+	positions     map[string]string
 }
 
 func (f *Fetcher) Ready() bool {
 	return f.ready.Load()
 }
 
-func NewFetcher(logger Logger, include []string, exclude []string, client *kubernetes.Clientset) (*Fetcher, error) {
+func NewFetcher(logger Logger, cfg FetcherConfig, client kubernetes.Interface) (*Fetcher, error) {
+	namespaces := watchNamespaces(cfg.IncludeNamespaces)
+
+	selectors := make(map[string]string, len(namespaces))
+	for _, ns := range namespaces {
+		selectors[ns] = cfg.FieldSelectors.fieldSelector(false, ns, cfg.ExcludeNamespaces)
+	}
+
 	return &Fetcher{
-		client:    client,
-		logger:    logger,
-		includeNS: toSet(include),
-		excludeNS: toSet(exclude),
+		client:        client,
+		logger:        logger,
+		includeNS:     toSet(cfg.IncludeNamespaces),
+		excludeNS:     toSet(cfg.ExcludeNamespaces),
+		nsFilter:      cfg.NamespaceFilter,
+		clusterID:     cfg.ClusterID,
+		namespaces:    namespaces,
+		fieldSelector: selectors,
+		exportDeleted: cfg.ExportDeleted,
+		checkpoint:    newCheckpointer(cfg.Checkpoint, cfg.CheckpointInterval, logger),
+		positions:     cfg.Positions,
 	}, nil
 }
 
+// FlushCheckpoint saves the positions confirmed since the last save. It is
+// called on shutdown after the writers delivered their final batches.
+func (f *Fetcher) FlushCheckpoint(ctx context.Context) {
+	f.checkpoint.flush(ctx)
+}
+
 func (f *Fetcher) Stream(ctx context.Context, out chan<- *domain.Event) error {
 	f.ready.Store(true)
 	defer f.ready.Store(false)
 
+	go f.checkpoint.run(ctx)
+
+	var wg sync.WaitGroup
+	for _, ns := range f.namespaces {
+		state := newWatchState(f.clusterID, ns, f.positions)
+		wg.Go(func() {
+			f.streamNamespace(ctx, state, out)
+		})
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+func (f *Fetcher) streamNamespace(ctx context.Context, st *watchState, out chan<- *domain.Event) {
 	backoff := time.Second
+	selector := f.fieldSelector[st.namespace]
+
+	f.logger.Info(ctx, "adapters:kubernetes:fetcher: starting watch",
+		"namespace", st.namespace,
+		"field_selector", selector,
+		"resource_version", st.resourceVersion,
+	)
 
 	for {
 		if ctx.Err() != nil {
-			return ctx.Err()
+			return
 		}
 
-		watcher, err := f.client.CoreV1().Events("").Watch(ctx, metav1.ListOptions{})
+		if st.resourceVersion == "" {
+			if err := f.relist(ctx, st, out); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				f.logger.Error(ctx, "adapters:kubernetes:fetcher: failed to list events", "namespace", st.namespace, "error", err)
+				time.Sleep(backoff)
+				backoff = nextBackoff(backoff)
+				continue
+			}
+		}
+
+		watcher, err := f.client.CoreV1().Events(st.namespace).Watch(ctx, metav1.ListOptions{
+			ResourceVersion:     st.resourceVersion,
+			AllowWatchBookmarks: true,
+			FieldSelector:       selector,
+		})
 		if err != nil {
-			f.logger.Error(ctx, "adapters:kubernetes:fetcher: failed to start watch", "error", err)
-			time.Sleep(backoff)
-			if backoff < 30*time.Second {
-				backoff *= 2
+			if isResourceExpired(err) {
+				f.logger.Info(ctx, "adapters:kubernetes:fetcher: resource version expired, relisting",
+					"namespace", st.namespace,
+					"resource_version", st.resourceVersion,
+				)
+				st.resourceVersion = ""
+				continue
 			}
+			f.logger.Error(ctx, "adapters:kubernetes:fetcher: failed to start watch", "namespace", st.namespace, "error", err)
+			time.Sleep(backoff)
+			backoff = nextBackoff(backoff)
 			continue
 		}
 
 		backoff = time.Second
 
+	watchLoop:
 		for evt := range watcher.ResultChan() {
-			k8sEvent, ok := evt.Object.(*corev1.Event)
-			if !ok {
+			switch evt.Type {
+			case watch.Error:
+				if isResourceExpired(statusError(evt.Object)) {
+					f.logger.Info(ctx, "adapters:kubernetes:fetcher: resource version expired, relisting",
+						"namespace", st.namespace,
+						"resource_version", st.resourceVersion,
+					)
+					st.resourceVersion = ""
+				} else {
+					f.logger.Error(ctx, "adapters:kubernetes:fetcher: watch returned an error",
+						append([]any{"namespace", st.namespace}, statusAttrs(evt.Object)...)...,
+					)
+				}
+				break watchLoop
+			case watch.Bookmark:
+				if rv := resourceVersionOf(evt.Object); rv != "" {
+					st.resourceVersion = rv
+					f.checkpoint.advance(st.key, rv)
+				}
 				continue
 			}
 
-			domainEvent, err := mapK8sEventToDomain(k8sEvent)
-			if err != nil {
-				f.logger.Warn(ctx, "adapters:kubernetes:fetcher: failed to map event", "error", err)
+			k8sEvent, ok := evt.Object.(*corev1.Event)
+			if !ok {
+				f.logger.Warn(ctx, "adapters:kubernetes:fetcher: unexpected object in watch", "type", fmt.Sprintf("%T", evt.Object))
 				continue
 			}
 
-			f.logger.Debug(ctx, "adapters:kubernetes:fetcher: received event",
-				"namespace", domainEvent.Namespace(),
-				"name", domainEvent.Name(),
-				"reason", domainEvent.Reason(),
-				"type", domainEvent.Type(),
-				"message", domainEvent.Message(),
-			)
-
-			ns := domainEvent.Namespace()
-
-			if len(f.includeNS) > 0 {
-				if _, ok := f.includeNS[ns]; !ok {
+			st.resourceVersion = k8sEvent.ResourceVersion
+			if evt.Type == watch.Deleted {
+				delete(st.seen, k8sEvent.UID)
+				if !f.exportDeleted {
+					f.checkpoint.advance(st.key, k8sEvent.ResourceVersion)
 					continue
 				}
+			} else {
+				st.seen[k8sEvent.UID] = k8sEvent.ResourceVersion
 			}
 
-			if _, ok := f.excludeNS[ns]; ok {
-				continue
-			}
-
-			select {
-			case <-ctx.Done():
+			if err := f.emit(ctx, st, out, k8sEvent, string(evt.Type), k8sEvent.ResourceVersion); err != nil {
 				watcher.Stop()
-				return ctx.Err()
-			case out <- domainEvent:
+				return
 			}
 		}
 
+		watcher.Stop()
+
 		if ctx.Err() != nil {
-			watcher.Stop()
-			return ctx.Err()
+			return
 		}
 
-		f.logger.Info(ctx, "adapters:kubernetes:fetcher: watch channel closed, reconnecting...")
+		f.logger.Info(ctx, "adapters:kubernetes:fetcher: watch channel closed, reconnecting...",
+			"namespace", st.namespace,
+			"resource_version", st.resourceVersion,
+		)
 		time.Sleep(backoff)
+		backoff = nextBackoff(backoff)
+	}
+}
+
+// relist pages through all events, forwards the ones not delivered yet and
+// sets the resume point to the list's resourceVersion.
+func (f *Fetcher) relist(ctx context.Context, st *watchState, out chan<- *domain.Event) error {
+	seen := make(map[types.UID]string)
+	opts := metav1.ListOptions{
+		Limit:         listPageSize,
+		FieldSelector: f.fieldSelector[st.namespace],
+	}
+	var listRV string
+
+	for {
+		list, err := f.client.CoreV1().Events(st.namespace).List(ctx, opts)
+		if err != nil {
+			return err
+		}
+		if listRV == "" {
+			listRV = list.ResourceVersion
+		}
+
+		for i := range list.Items {
+			k8sEvent := &list.Items[i]
+			seen[k8sEvent.UID] = k8sEvent.ResourceVersion
+
+			if rv, ok := st.seen[k8sEvent.UID]; ok && rv == k8sEvent.ResourceVersion {
+				continue
+			}
+			// list items come in key order, their own resourceVersions are
+			// no resume point; listRV is committed after all of them
+			if err := f.emit(ctx, st, out, k8sEvent, string(watch.Added), ""); err != nil {
+				return err
+			}
+		}
 
-		if backoff < 30*time.Second {
-			backoff *= 2
+		if list.Continue == "" {
+			break
 		}
+		opts.Continue = list.Continue
 	}
+
+	st.seen = seen
+	st.resourceVersion = listRV
+	f.checkpoint.advance(st.key, listRV)
+
+	f.logger.Debug(ctx, "adapters:kubernetes:fetcher: relist complete",
+		"namespace", st.namespace,
+		"events", len(seen),
+		"resource_version", listRV,
+	)
+	return nil
 }
 
-func mapK8sEventToDomain(e *corev1.Event) (*domain.Event, error) {
+func (f *Fetcher) emit(ctx context.Context, st *watchState, out chan<- *domain.Event, k8sEvent *corev1.Event, watchType, position string) error {
+	delivered := f.checkpoint.track(st.key, position)
+
+	domainEvent, err := mapK8sEventToDomain(k8sEvent)
+	if err != nil {
+		f.logger.Warn(ctx, "adapters:kubernetes:fetcher: failed to map event", "error", err)
+		delivered()
+		return nil
+	}
+	domainEvent.SetWatchType(watchType)
+	domainEvent.SetClusterID(f.clusterID)
+	domainEvent.SetDeliveryHook(delivered)
+
+	f.logger.Debug(ctx, "adapters:kubernetes:fetcher: received event",
+		"namespace", domainEvent.Namespace(),
+		"name", domainEvent.Name(),
+		"reason", domainEvent.Reason(),
+		"type", domainEvent.Type(),
+		"message", domainEvent.Message(),
+	)
+
+	if !namespaceAllowed(f.includeNS, f.excludeNS, domainEvent.Namespace()) || !f.nsFilter.Allowed(domainEvent.Namespace()) {
+		delivered()
+		return nil
+	}
 
-	obj := domain.ObjectRef{
-		Kind:      e.InvolvedObject.Kind,
-		Name:      e.InvolvedObject.Name,
-		Namespace: e.InvolvedObject.Namespace,
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case out <- domainEvent:
 	}
+	return nil
+}
+
+// lostFieldsCoreV1 lists the core/v1 Event fields mapK8sEventToDomain drops.
+// eventTime and reportingComponent are only used when firstTimestamp and
+// source.component are empty.
+var lostFieldsCoreV1 = []string{
+	"eventTime",
+	"reportingComponent",
+}
 
+func mapK8sEventToDomain(e *corev1.Event) (*domain.Event, error) {
+	obj := objectRefFromCore(e.InvolvedObject)
+
+	// events written through events.k8s.io/v1 only carry eventTime
 	eventTime := e.FirstTimestamp.Time
+	if eventTime.IsZero() {
+		eventTime = e.EventTime.Time
+	}
 	lastTime := timePtr(e.LastTimestamp.Time)
 
-	return domain.NewEvent(
+	source := e.Source.Component
+	if source == "" {
+		source = e.ReportingController
+	}
+
+	count := e.Count
+	if e.Series != nil {
+		count = e.Series.Count
+	}
+
+	ev, err := domain.NewEvent(
 		string(e.UID),
 		e.Name,
 		e.Namespace,
@@ -141,9 +376,39 @@ func mapK8sEventToDomain(e *corev1.Event) (*domain.Event, error) {
 		e.Message,
 		e.Type,
 		obj,
-		e.Source.Component,
+		source,
 		eventTime,
 		lastTime,
-		e.Count,
+		count,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	details := domain.EventDetails{
+		Action:            e.Action,
+		ReportingInstance: e.ReportingInstance,
+		SourceHost:        e.Source.Host,
+	}
+	if e.Related != nil {
+		related := objectRefFromCore(*e.Related)
+		details.Related = &related
+	}
+	if e.Series != nil {
+		details.SeriesLastObservedTime = timePtr(e.Series.LastObservedTime.Time)
+	}
+	ev.SetDetails(details)
+
+	return ev, nil
+}
+
+func objectRefFromCore(ref corev1.ObjectReference) domain.ObjectRef {
+	return domain.ObjectRef{
+		Kind:       ref.Kind,
+		Name:       ref.Name,
+		Namespace:  ref.Namespace,
+		UID:        string(ref.UID),
+		APIVersion: ref.APIVersion,
+		FieldPath:  ref.FieldPath,
+	}
 }
@@ -0,0 +1,259 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"event_exporter/internal/domain"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type eventStreamer interface {
+	Stream(ctx context.Context, out chan<- *domain.Event) error
+	FlushCheckpoint(ctx context.Context)
+}
+
+// eventAPI builds fetchers and objects for one of the two Event APIs.
+type eventAPI struct {
+	newFetcher func(cfg FetcherConfig, client kubernetes.Interface) (eventStreamer, error)
+	event      func(uid, rv string) runtime.Object
+	list       func(rv string, items ...runtime.Object) runtime.Object
+}
+
+var eventAPIs = map[string]eventAPI{
+	"core/v1": {
+		newFetcher: func(cfg FetcherConfig, client kubernetes.Interface) (eventStreamer, error) {
+			return NewFetcher(nopLogger{}, cfg, client)
+		},
+		event: func(uid, rv string) runtime.Object {
+			return &corev1.Event{
+				ObjectMeta:     metav1.ObjectMeta{Name: uid, Namespace: "apps", UID: types.UID(uid), ResourceVersion: rv},
+				InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web", Namespace: "apps"},
+				Reason:         "Started",
+				Message:        "Started container web",
+				Type:           corev1.EventTypeNormal,
+				FirstTimestamp: metav1.Now(),
+			}
+		},
+		list: func(rv string, items ...runtime.Object) runtime.Object {
+			list := &corev1.EventList{ListMeta: metav1.ListMeta{ResourceVersion: rv}}
+			for _, item := range items {
+				list.Items = append(list.Items, *item.(*corev1.Event))
+			}
+			return list
+		},
+	},
+	"events.k8s.io/v1": {
+		newFetcher: func(cfg FetcherConfig, client kubernetes.Interface) (eventStreamer, error) {
+			return NewFetcherV1(nopLogger{}, cfg, client)
+		},
+		event: func(uid, rv string) runtime.Object {
+			return &eventsv1.Event{
+				ObjectMeta: metav1.ObjectMeta{Name: uid, Namespace: "apps", UID: types.UID(uid), ResourceVersion: rv},
+				Regarding:  corev1.ObjectReference{Kind: "Pod", Name: "web", Namespace: "apps"},
+				Reason:     "Started",
+				Note:       "Started container web",
+				Type:       corev1.EventTypeNormal,
+				EventTime:  metav1.NowMicro(),
+			}
+		},
+		list: func(rv string, items ...runtime.Object) runtime.Object {
+			list := &eventsv1.EventList{ListMeta: metav1.ListMeta{ResourceVersion: rv}}
+			for _, item := range items {
+				list.Items = append(list.Items, *item.(*eventsv1.Event))
+			}
+			return list
+		},
+	},
+}
+
+// fakeEvents serves the watches and the list of a fake clientset and
+// records the resourceVersions the fetcher asked for.
+type fakeEvents struct {
+	mu       sync.Mutex
+	watchRVs []string
+	lists    int
+	watchers []*watch.FakeWatcher
+}
+
+func newFakeEvents(list runtime.Object, watchers int) (*fakeEvents, *fake.Clientset) {
+	fe := &fakeEvents{}
+	for range watchers {
+		fe.watchers = append(fe.watchers, watch.NewFakeWithChanSize(10, false))
+	}
+
+	client := fake.NewClientset()
+	client.PrependReactor("list", "events", func(k8stesting.Action) (bool, runtime.Object, error) {
+		fe.mu.Lock()
+		defer fe.mu.Unlock()
+		fe.lists++
+		return true, list, nil
+	})
+	client.PrependWatchReactor("events", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		fe.mu.Lock()
+		defer fe.mu.Unlock()
+		fe.watchRVs = append(fe.watchRVs, action.(k8stesting.WatchActionImpl).GetWatchRestrictions().ResourceVersion)
+		w := fe.watchers[len(fe.watchRVs)-1]
+		return true, w, nil
+	})
+	return fe, client
+}
+
+func (fe *fakeEvents) requested() ([]string, int) {
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+	return slices.Clone(fe.watchRVs), fe.lists
+}
+
+// startFetcher runs the fetcher until the test ends.
+func startFetcher(t *testing.T, api eventAPI, client kubernetes.Interface, store *memStore, fe *fakeEvents) (eventStreamer, <-chan *domain.Event) {
+	t.Helper()
+	f, err := api.newFetcher(FetcherConfig{
+		ClusterID:          "test",
+		Checkpoint:         store,
+		CheckpointInterval: time.Hour,
+		Positions:          map[string]string{"test._all": "100"},
+	}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan *domain.Event, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = f.Stream(ctx, out)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		for _, w := range fe.watchers {
+			w.Stop()
+		}
+		<-done
+	})
+	return f, out
+}
+
+func receive(t *testing.T, out <-chan *domain.Event, n int) []*domain.Event {
+	t.Helper()
+	var events []*domain.Event
+	for range n {
+		select {
+		case ev := <-out:
+			events = append(events, ev)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d events, want %d", len(events), n)
+		}
+	}
+	return events
+}
+
+func uids(events []*domain.Event) []string {
+	var out []string
+	for _, ev := range events {
+		out = append(out, ev.UID())
+	}
+	return out
+}
+
+func TestFetcherResumesFromCheckpoint(t *testing.T) {
+	for name, api := range eventAPIs {
+		t.Run(name, func(t *testing.T) {
+			store := &memStore{}
+			fe, client := newFakeEvents(api.list("0"), 1)
+			f, out := startFetcher(t, api, client, store, fe)
+
+			w := fe.watchers[0]
+			w.Add(api.event("a", "101"))
+			bookmark := api.event("", "150")
+			w.Action(watch.Bookmark, bookmark)
+			w.Add(api.event("b", "151"))
+
+			events := receive(t, out, 2)
+			if got := uids(events); !slices.Equal(got, []string{"a", "b"}) {
+				t.Fatalf("forwarded %v, want [a b]", got)
+			}
+			if rvs, lists := fe.requested(); !slices.Equal(rvs, []string{"100"}) || lists != 0 {
+				t.Fatalf("watched from %v after %d lists, want [100] without a list", rvs, lists)
+			}
+
+			// the bookmark is committed once the event before it is delivered
+			events[0].Delivered()
+			f.FlushCheckpoint(context.Background())
+			if got := store.saved["test._all"]; got != "150" {
+				t.Fatalf("checkpoint = %q, want 150", got)
+			}
+
+			events[1].Delivered()
+			f.FlushCheckpoint(context.Background())
+			if got := store.saved["test._all"]; got != "151" {
+				t.Fatalf("checkpoint = %q, want 151", got)
+			}
+		})
+	}
+}
+
+func TestFetcherRelistsAfterExpiredResourceVersion(t *testing.T) {
+	for name, api := range eventAPIs {
+		t.Run(name, func(t *testing.T) {
+			store := &memStore{}
+			// a is unchanged since the watch forwarded it, b changed and c
+			// is new
+			list := api.list("200", api.event("a", "101"), api.event("b", "160"), api.event("c", "170"))
+			fe, client := newFakeEvents(list, 2)
+			f, out := startFetcher(t, api, client, store, fe)
+
+			w := fe.watchers[0]
+			w.Add(api.event("a", "101"))
+			w.Add(api.event("b", "102"))
+			w.Error(&apierrors.NewResourceExpired("too old resource version").ErrStatus)
+
+			events := receive(t, out, 4)
+			if got := uids(events); !slices.Equal(got, []string{"a", "b", "b", "c"}) {
+				t.Fatalf("forwarded %v, want [a b b c]", got)
+			}
+			for _, ev := range events {
+				ev.Delivered()
+			}
+
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				rvs, lists := fe.requested()
+				if len(rvs) == 2 {
+					if !slices.Equal(rvs, []string{"100", "200"}) || lists != 1 {
+						t.Fatalf("watched from %v after %d lists, want [100 200] after one list", rvs, lists)
+					}
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("no watch after the relist, watched from %v", rvs)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			f.FlushCheckpoint(context.Background())
+			if got := store.saved["test._all"]; got != "200" {
+				t.Fatalf("checkpoint = %q, want 200", got)
+			}
+		})
+	}
+}
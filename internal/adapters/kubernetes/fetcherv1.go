@@ -10,11 +10,15 @@ package kubernetes
 import (
 	"context"
 	"event_exporter/internal/domain"
+	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	eventv1 "k8s.io/api/events/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -26,115 +30,283 @@ type LoggerV1 interface {
 }
 
 type FetcherV1 struct {
-	client    *kubernetes.Clientset
+	client    kubernetes.Interface
 	logger    LoggerV1
 	includeNS map[string]struct{}
 	excludeNS map[string]struct{}
+	nsFilter  *NamespaceFilter
 	ready     atomic.Bool
+
+	clusterID     string
+	namespaces    []string
+	fieldSelector map[string]string
+	exportDeleted bool
+	checkpoint    *checkpointer
+	positions     map[string]string
 }
 
 func (f *FetcherV1) Ready() bool {
 	return f.ready.Load()
 }
 
-func NewFetcherV1(logger LoggerV1, include []string, exclude []string, client *kubernetes.Clientset) (*FetcherV1, error) {
+func NewFetcherV1(logger LoggerV1, cfg FetcherConfig, client kubernetes.Interface) (*FetcherV1, error) {
+	namespaces := watchNamespaces(cfg.IncludeNamespaces)
+
+	selectors := make(map[string]string, len(namespaces))
+	for _, ns := range namespaces {
+		selectors[ns] = cfg.FieldSelectors.fieldSelector(true, ns, cfg.ExcludeNamespaces)
+	}
+
 	return &FetcherV1{
-		client:    client,
-		logger:    logger,
-		includeNS: toSet(include),
-		excludeNS: toSet(exclude),
+		client:        client,
+		logger:        logger,
+		includeNS:     toSet(cfg.IncludeNamespaces),
+		excludeNS:     toSet(cfg.ExcludeNamespaces),
+		nsFilter:      cfg.NamespaceFilter,
+		clusterID:     cfg.ClusterID,
+		namespaces:    namespaces,
+		fieldSelector: selectors,
+		exportDeleted: cfg.ExportDeleted,
+		checkpoint:    newCheckpointer(cfg.Checkpoint, cfg.CheckpointInterval, logger),
+		positions:     cfg.Positions,
 	}, nil
 
 }
 
+// FlushCheckpoint saves the positions confirmed since the last save. It is
+// called on shutdown after the writers delivered their final batches.
+func (f *FetcherV1) FlushCheckpoint(ctx context.Context) {
+	f.checkpoint.flush(ctx)
+}
+
 func (f *FetcherV1) Stream(ctx context.Context, out chan<- *domain.Event) error {
 	f.ready.Store(true)
 	defer f.ready.Store(false)
 
+	go f.checkpoint.run(ctx)
+
+	var wg sync.WaitGroup
+	for _, ns := range f.namespaces {
+		state := newWatchState(f.clusterID, ns, f.positions)
+		wg.Go(func() {
+			f.streamNamespace(ctx, state, out)
+		})
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+func (f *FetcherV1) streamNamespace(ctx context.Context, st *watchState, out chan<- *domain.Event) {
 	backoff := time.Second
+	selector := f.fieldSelector[st.namespace]
+
+	f.logger.Info(ctx, "adapters:kubernetes:fetcherv1: starting watch",
+		"namespace", st.namespace,
+		"field_selector", selector,
+		"resource_version", st.resourceVersion,
+	)
 
 	for {
 		if ctx.Err() != nil {
-			return ctx.Err()
+			return
+		}
+
+		if st.resourceVersion == "" {
+			if err := f.relist(ctx, st, out); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				f.logger.Error(ctx, "adapters:kubernetes:fetcherv1: failed to list events", "namespace", st.namespace, "error", err)
+				time.Sleep(backoff)
+				backoff = nextBackoff(backoff)
+				continue
+			}
 		}
 
-		watcher, err := f.client.EventsV1().Events("").Watch(ctx, metav1.ListOptions{})
+		watcher, err := f.client.EventsV1().Events(st.namespace).Watch(ctx, metav1.ListOptions{
+			ResourceVersion:     st.resourceVersion,
+			AllowWatchBookmarks: true,
+			FieldSelector:       selector,
+		})
 		if err != nil {
-			f.logger.Error(ctx, "adapters:kubernetes:fetcherv1: failed to start watch", "error", err)
-			time.Sleep(backoff)
-			if backoff < 30*time.Second {
-				backoff *= 2
+			if isResourceExpired(err) {
+				f.logger.Info(ctx, "adapters:kubernetes:fetcherv1: resource version expired, relisting",
+					"namespace", st.namespace,
+					"resource_version", st.resourceVersion,
+				)
+				st.resourceVersion = ""
+				continue
 			}
+			f.logger.Error(ctx, "adapters:kubernetes:fetcherv1: failed to start watch", "namespace", st.namespace, "error", err)
+			time.Sleep(backoff)
+			backoff = nextBackoff(backoff)
 			continue
 		}
 
 		backoff = time.Second
 
+	watchLoop:
 		for evt := range watcher.ResultChan() {
-			k8sEvent, ok := evt.Object.(*eventv1.Event)
-			if !ok {
+			switch evt.Type {
+			case watch.Error:
+				if isResourceExpired(statusError(evt.Object)) {
+					f.logger.Info(ctx, "adapters:kubernetes:fetcherv1: resource version expired, relisting",
+						"namespace", st.namespace,
+						"resource_version", st.resourceVersion,
+					)
+					st.resourceVersion = ""
+				} else {
+					f.logger.Error(ctx, "adapters:kubernetes:fetcherv1: watch returned an error",
+						append([]any{"namespace", st.namespace}, statusAttrs(evt.Object)...)...,
+					)
+				}
+				break watchLoop
+			case watch.Bookmark:
+				if rv := resourceVersionOf(evt.Object); rv != "" {
+					st.resourceVersion = rv
+					f.checkpoint.advance(st.key, rv)
+				}
 				continue
 			}
 
-			domainEvent, err := mapK8sEventV1ToDomain(k8sEvent)
-			if err != nil {
-				f.logger.Warn(ctx, "adapters:kubernetes:fetcherv1: failed to map event", "error", err)
+			k8sEvent, ok := evt.Object.(*eventv1.Event)
+			if !ok {
+				f.logger.Warn(ctx, "adapters:kubernetes:fetcherv1: unexpected object in watch", "type", fmt.Sprintf("%T", evt.Object))
 				continue
 			}
 
-			f.logger.Debug(
-				ctx, "adapters:kubernetes:fetcherv1: received event",
-				"namespace", domainEvent.Namespace(),
-				"name", domainEvent.Name(),
-				"reason", domainEvent.Reason(),
-				"type", domainEvent.Type(),
-				"message", domainEvent.Message(),
-			)
-
-			ns := domainEvent.Namespace()
-
-			if len(f.includeNS) > 0 {
-				if _, ok := f.includeNS[ns]; !ok {
+			st.resourceVersion = k8sEvent.ResourceVersion
+			if evt.Type == watch.Deleted {
+				delete(st.seen, k8sEvent.UID)
+				if !f.exportDeleted {
+					f.checkpoint.advance(st.key, k8sEvent.ResourceVersion)
 					continue
 				}
+			} else {
+				st.seen[k8sEvent.UID] = k8sEvent.ResourceVersion
 			}
 
-			if _, ok := f.excludeNS[ns]; ok {
-				continue
-			}
-
-			select {
-			case <-ctx.Done():
+			if err := f.emit(ctx, st, out, k8sEvent, string(evt.Type), k8sEvent.ResourceVersion); err != nil {
 				watcher.Stop()
-				return ctx.Err()
-			case out <- domainEvent:
+				return
 			}
 		}
+
+		watcher.Stop()
+
 		if ctx.Err() != nil {
-			watcher.Stop()
-			return ctx.Err()
+			return
 		}
 
-		f.logger.Info(ctx, "adapters:kubernetes:fetcherv1: watch channel closed, reconnecting...")
+		f.logger.Info(ctx, "adapters:kubernetes:fetcherv1: watch channel closed, reconnecting...",
+			"namespace", st.namespace,
+			"resource_version", st.resourceVersion,
+		)
 		time.Sleep(backoff)
+		backoff = nextBackoff(backoff)
+	}
+}
 
-		if backoff < 30*time.Second {
-			backoff *= 2
+// relist pages through all events, forwards the ones not delivered yet and
+// sets the resume point to the list's resourceVersion.
+func (f *FetcherV1) relist(ctx context.Context, st *watchState, out chan<- *domain.Event) error {
+	seen := make(map[types.UID]string)
+	opts := metav1.ListOptions{
+		Limit:         listPageSize,
+		FieldSelector: f.fieldSelector[st.namespace],
+	}
+	var listRV string
+
+	for {
+		list, err := f.client.EventsV1().Events(st.namespace).List(ctx, opts)
+		if err != nil {
+			return err
+		}
+		if listRV == "" {
+			listRV = list.ResourceVersion
+		}
+
+		for i := range list.Items {
+			k8sEvent := &list.Items[i]
+			seen[k8sEvent.UID] = k8sEvent.ResourceVersion
+
+			if rv, ok := st.seen[k8sEvent.UID]; ok && rv == k8sEvent.ResourceVersion {
+				continue
+			}
+			// list items come in key order, their own resourceVersions are
+			// no resume point; listRV is committed after all of them
+			if err := f.emit(ctx, st, out, k8sEvent, string(watch.Added), ""); err != nil {
+				return err
+			}
 		}
+
+		if list.Continue == "" {
+			break
+		}
+		opts.Continue = list.Continue
 	}
+
+	st.seen = seen
+	st.resourceVersion = listRV
+	f.checkpoint.advance(st.key, listRV)
+
+	f.logger.Debug(ctx, "adapters:kubernetes:fetcherv1: relist complete",
+		"namespace", st.namespace,
+		"events", len(seen),
+		"resource_version", listRV,
+	)
+	return nil
 }
 
-func mapK8sEventV1ToDomain(e *eventv1.Event) (*domain.Event, error) {
-	obj := domain.ObjectRef{
-		Kind:      e.Regarding.Kind,
-		Name:      e.Regarding.Name,
-		Namespace: e.Regarding.Namespace,
+func (f *FetcherV1) emit(ctx context.Context, st *watchState, out chan<- *domain.Event, k8sEvent *eventv1.Event, watchType, position string) error {
+	delivered := f.checkpoint.track(st.key, position)
+
+	domainEvent, err := mapK8sEventV1ToDomain(k8sEvent)
+	if err != nil {
+		f.logger.Warn(ctx, "adapters:kubernetes:fetcherv1: failed to map event", "error", err)
+		delivered()
+		return nil
 	}
+	domainEvent.SetWatchType(watchType)
+	domainEvent.SetClusterID(f.clusterID)
+	domainEvent.SetDeliveryHook(delivered)
+
+	f.logger.Debug(ctx, "adapters:kubernetes:fetcherv1: received event",
+		"namespace", domainEvent.Namespace(),
+		"name", domainEvent.Name(),
+		"reason", domainEvent.Reason(),
+		"type", domainEvent.Type(),
+		"message", domainEvent.Message(),
+	)
+
+	if !namespaceAllowed(f.includeNS, f.excludeNS, domainEvent.Namespace()) || !f.nsFilter.Allowed(domainEvent.Namespace()) {
+		delivered()
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case out <- domainEvent:
+	}
+	return nil
+}
+
+// lostFieldsV1 lists the events.k8s.io/v1 Event fields mapK8sEventV1ToDomain
+// drops. deprecatedFirstTimestamp is only used when eventTime is empty.
+var lostFieldsV1 = []string{
+	"deprecatedSource.component",
+	"deprecatedFirstTimestamp",
+}
+
+func mapK8sEventV1ToDomain(e *eventv1.Event) (*domain.Event, error) {
+	obj := objectRefFromCore(e.Regarding)
 
 	eventTime := extractEventTime(e)
 	lastTime := timePtr(e.DeprecatedLastTimestamp.Time)
 
-	return domain.NewEvent(
+	ev, err := domain.NewEvent(
 		string(e.UID),
 		e.Name,
 		e.Namespace,
@@ -147,6 +319,25 @@ func mapK8sEventV1ToDomain(e *eventv1.Event) (*domain.Event, error) {
 		lastTime,
 		safeCount(e),
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	details := domain.EventDetails{
+		Action:            e.Action,
+		ReportingInstance: e.ReportingInstance,
+		SourceHost:        e.DeprecatedSource.Host,
+	}
+	if e.Related != nil {
+		related := objectRefFromCore(*e.Related)
+		details.Related = &related
+	}
+	if e.Series != nil {
+		details.SeriesLastObservedTime = timePtr(e.Series.LastObservedTime.Time)
+	}
+	ev.SetDetails(details)
+
+	return ev, nil
 }
 
 func safeCount(e *eventv1.Event) int32 {
@@ -1,6 +1,26 @@
 package kubernetes
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// listPageSize limits how many events a single List call returns.
+const listPageSize = 500
+
+// LostFields returns the Event fields of the given API that KENT does not
+// carry into the exported log entry.
+func LostFields(eventsV1 bool) []string {
+	if eventsV1 {
+		return lostFieldsV1
+	}
+	return lostFieldsCoreV1
+}
 
 func timePtr(t time.Time) *time.Time {
 	if t.IsZero() {
@@ -16,3 +36,55 @@ func toSet(list []string) map[string]struct{} {
 	}
 	return set
 }
+
+// namespaceAllowed applies the include/exclude namespace filters.
+func namespaceAllowed(include, exclude map[string]struct{}, ns string) bool {
+	if len(include) > 0 {
+		if _, ok := include[ns]; !ok {
+			return false
+		}
+	}
+	_, excluded := exclude[ns]
+	return !excluded
+}
+
+func nextBackoff(d time.Duration) time.Duration {
+	if d < 30*time.Second {
+		return d * 2
+	}
+	return d
+}
+
+// isResourceExpired reports whether the API server rejected a resourceVersion
+// as too old ("410 Gone"), meaning the caller has to relist.
+func isResourceExpired(err error) bool {
+	return apierrors.IsResourceExpired(err) || apierrors.IsGone(err)
+}
+
+// statusError converts the object of a watch.Error notification into an error.
+func statusError(obj runtime.Object) error {
+	return apierrors.FromObject(obj)
+}
+
+// statusAttrs turns the metav1.Status carried by a watch.Error notification
+// into logger key/value pairs.
+func statusAttrs(obj runtime.Object) []any {
+	status, ok := obj.(*metav1.Status)
+	if !ok {
+		return []any{"object", fmt.Sprintf("%T", obj)}
+	}
+	return []any{
+		"code", status.Code,
+		"reason", status.Reason,
+		"message", status.Message,
+	}
+}
+
+// resourceVersionOf returns the resourceVersion of obj, e.g. of a BOOKMARK.
+func resourceVersionOf(obj any) string {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return m.GetResourceVersion()
+}
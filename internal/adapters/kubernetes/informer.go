@@ -0,0 +1,179 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"event_exporter/internal/domain"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	eventv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// InformerFetcher streams events from a SharedInformer, leaving list+watch,
+// relisting and bookmarks to client-go's Reflector.
+type InformerFetcher struct {
+	client        *kubernetes.Clientset
+	logger        Logger
+	eventsV1      bool
+	resync        time.Duration
+	includeNS     map[string]struct{}
+	excludeNS     map[string]struct{}
+	nsFilter      *NamespaceFilter
+	clusterID     string
+	namespaces    []string
+	fieldSelector map[string]string
+	exportDeleted bool
+	ready         atomic.Bool
+}
+
+// Ready reports true once the initial cache sync has completed.
+func (f *InformerFetcher) Ready() bool {
+	return f.ready.Load()
+}
+
+// NewInformerFetcher builds an informer on events.k8s.io/v1 when eventsV1 is
+// set and on core/v1 otherwise.
+func NewInformerFetcher(logger Logger, cfg FetcherConfig, eventsV1 bool, resync time.Duration, client *kubernetes.Clientset) (*InformerFetcher, error) {
+	namespaces := watchNamespaces(cfg.IncludeNamespaces)
+
+	selectors := make(map[string]string, len(namespaces))
+	for _, ns := range namespaces {
+		selectors[ns] = cfg.FieldSelectors.fieldSelector(eventsV1, ns, cfg.ExcludeNamespaces)
+	}
+
+	return &InformerFetcher{
+		client:        client,
+		logger:        logger,
+		eventsV1:      eventsV1,
+		resync:        resync,
+		includeNS:     toSet(cfg.IncludeNamespaces),
+		excludeNS:     toSet(cfg.ExcludeNamespaces),
+		nsFilter:      cfg.NamespaceFilter,
+		clusterID:     cfg.ClusterID,
+		namespaces:    namespaces,
+		fieldSelector: selectors,
+		exportDeleted: cfg.ExportDeleted,
+	}, nil
+}
+
+func (f *InformerFetcher) Stream(ctx context.Context, out chan<- *domain.Event) error {
+	defer f.ready.Store(false)
+
+	handlers := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			f.handle(ctx, out, obj, watch.Added)
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			// periodic resyncs re-deliver unchanged objects
+			if resourceVersionOf(oldObj) == resourceVersionOf(newObj) {
+				return
+			}
+			f.handle(ctx, out, newObj, watch.Modified)
+		},
+		DeleteFunc: func(obj any) {
+			if !f.exportDeleted {
+				return
+			}
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			f.handle(ctx, out, obj, watch.Deleted)
+		},
+	}
+
+	// one factory per namespace: each one lists and watches only its namespace
+	var synced []cache.InformerSynced
+	for _, ns := range f.namespaces {
+		selector := f.fieldSelector[ns]
+		factory := informers.NewSharedInformerFactoryWithOptions(f.client, f.resync,
+			informers.WithNamespace(ns),
+			informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+				opts.FieldSelector = selector
+			}),
+		)
+
+		var informer cache.SharedIndexInformer
+		if f.eventsV1 {
+			informer = factory.Events().V1().Events().Informer()
+		} else {
+			informer = factory.Core().V1().Events().Informer()
+		}
+
+		if _, err := informer.AddEventHandler(handlers); err != nil {
+			return fmt.Errorf("adapters:kubernetes:informer: failed to register handler: %w", err)
+		}
+
+		factory.Start(ctx.Done())
+		defer factory.Shutdown()
+
+		synced = append(synced, informer.HasSynced)
+	}
+
+	f.logger.Info(ctx, "adapters:kubernetes:informer: waiting for initial cache sync",
+		"events_v1", f.eventsV1,
+		"namespaces", f.namespaces,
+	)
+
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		return ctx.Err()
+	}
+
+	f.ready.Store(true)
+	f.logger.Info(ctx, "adapters:kubernetes:informer: cache synced")
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (f *InformerFetcher) handle(ctx context.Context, out chan<- *domain.Event, obj any, watchType watch.EventType) {
+	var (
+		domainEvent *domain.Event
+		err         error
+	)
+
+	switch e := obj.(type) {
+	case *corev1.Event:
+		domainEvent, err = mapK8sEventToDomain(e)
+	case *eventv1.Event:
+		domainEvent, err = mapK8sEventV1ToDomain(e)
+	default:
+		f.logger.Warn(ctx, "adapters:kubernetes:informer: unexpected object in cache", "type", fmt.Sprintf("%T", obj))
+		return
+	}
+	if err != nil {
+		f.logger.Warn(ctx, "adapters:kubernetes:informer: failed to map event", "error", err)
+		return
+	}
+	domainEvent.SetWatchType(string(watchType))
+	domainEvent.SetClusterID(f.clusterID)
+
+	f.logger.Debug(ctx, "adapters:kubernetes:informer: received event",
+		"namespace", domainEvent.Namespace(),
+		"name", domainEvent.Name(),
+		"reason", domainEvent.Reason(),
+		"type", domainEvent.Type(),
+		"message", domainEvent.Message(),
+	)
+
+	if !namespaceAllowed(f.includeNS, f.excludeNS, domainEvent.Namespace()) || !f.nsFilter.Allowed(domainEvent.Namespace()) {
+		return
+	}
+
+	select {
+	case <-ctx.Done():
+	case out <- domainEvent:
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"event_exporter/internal/domain"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	logTypeJob = "job"
+	sourceJob  = "kent-jobs"
+
+	// jobsCheckpointKey stores the finish time of the last reported Job;
+	// namespaces cannot start with "_", so it never collides with a watch.
+	jobsCheckpointKey = "_jobs"
+)
+
+// JobFetcher watches Jobs and emits one log type "job" record when a Job
+// completes or fails, with its duration, pod counts, parent CronJob and
+// failure reason.
+//
+// With a checkpoint store the finish time of the last delivered Job is
+// persisted, so Jobs that finished while KENT was down are reported on the
+// next start. Without one, only Jobs finishing after the start are.
+type JobFetcher struct {
+	client     kubernetes.Interface
+	logger     Logger
+	resync     time.Duration
+	clusterID  string
+	namespaces []string
+	includeNS  map[string]struct{}
+	excludeNS  map[string]struct{}
+	nsFilter   *NamespaceFilter
+	ready      atomic.Bool
+
+	checkpoint *checkpointer
+	key        string
+	// since is the persisted finish time of the last reported Job.
+	since time.Time
+
+	mu     sync.Mutex
+	latest time.Time
+}
+
+func NewJobFetcher(logger Logger, cfg FetcherConfig, resync time.Duration, client kubernetes.Interface) *JobFetcher {
+	key := checkpointKey(cfg.ClusterID, jobsCheckpointKey)
+
+	var since time.Time
+	if v := cfg.Positions[key]; v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			logger.Warn(context.Background(), "adapters:kubernetes:jobs: ignoring invalid checkpoint", "key", key, "value", v, "error", err)
+		} else {
+			since = t
+		}
+	}
+
+	return &JobFetcher{
+		client:     client,
+		logger:     logger,
+		resync:     resync,
+		clusterID:  cfg.ClusterID,
+		namespaces: watchNamespaces(cfg.IncludeNamespaces),
+		includeNS:  toSet(cfg.IncludeNamespaces),
+		excludeNS:  toSet(cfg.ExcludeNamespaces),
+		nsFilter:   cfg.NamespaceFilter,
+		checkpoint: newCheckpointer(cfg.Checkpoint, cfg.CheckpointInterval, logger),
+		key:        key,
+		since:      since,
+	}
+}
+
+// FlushCheckpoint saves the finish time of the last delivered Job. It is
+// called on shutdown after the writers delivered their final batches.
+func (f *JobFetcher) FlushCheckpoint(ctx context.Context) {
+	f.checkpoint.flush(ctx)
+}
+
+// Ready reports true once the job caches synced.
+func (f *JobFetcher) Ready() bool {
+	return f.ready.Load()
+}
+
+func (f *JobFetcher) Stream(ctx context.Context, out chan<- *domain.Event) error {
+	defer f.ready.Store(false)
+
+	go f.checkpoint.run(ctx)
+
+	// Jobs of the initial list that finished after the last reported one,
+	// i.e. while KENT was not running, are reported; older ones were
+	// reported by the previous run. Without a checkpoint the start is used.
+	since := f.since
+	if since.IsZero() {
+		since = time.Now().Truncate(time.Second)
+	}
+
+	report := func(job *batchv1.Job, cond batchv1.JobCondition) {
+		if !namespaceAllowed(f.includeNS, f.excludeNS, job.Namespace) || !f.nsFilter.Allowed(job.Namespace) {
+			return
+		}
+
+		ev, err := f.newEvent(job, cond)
+		if err != nil {
+			f.logger.Warn(ctx, "adapters:kubernetes:jobs: failed to build event", "job", job.Name, "error", err)
+			return
+		}
+		ev.SetDeliveryHook(f.checkpoint.track(f.key, f.position(jobFinished(job, cond))))
+		select {
+		case <-ctx.Done():
+		case out <- ev:
+		}
+	}
+
+	handlers := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			job, ok := obj.(*batchv1.Job)
+			if !ok {
+				return
+			}
+			if cond, ok := finishedAfter(job, since); ok {
+				report(job, cond)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			oldJob, ok1 := oldObj.(*batchv1.Job)
+			newJob, ok2 := newObj.(*batchv1.Job)
+			if !ok1 || !ok2 || oldJob.ResourceVersion == newJob.ResourceVersion {
+				return
+			}
+			if _, done := jobOutcome(oldJob); done {
+				return
+			}
+			cond, done := jobOutcome(newJob)
+			if done {
+				report(newJob, cond)
+			}
+		},
+	}
+
+	var synced []cache.InformerSynced
+	for _, ns := range f.namespaces {
+		factory := informers.NewSharedInformerFactoryWithOptions(f.client, f.resync, informers.WithNamespace(ns))
+		informer := factory.Batch().V1().Jobs().Informer()
+		if _, err := informer.AddEventHandler(handlers); err != nil {
+			return fmt.Errorf("adapters:kubernetes:jobs: failed to register handler: %w", err)
+		}
+		factory.Start(ctx.Done())
+		defer factory.Shutdown()
+		synced = append(synced, informer.HasSynced)
+	}
+
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		return ctx.Err()
+	}
+	f.ready.Store(true)
+	f.logger.Info(ctx, "adapters:kubernetes:jobs: job cache synced", "cluster", f.clusterID, "namespaces", f.namespaces)
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// position returns the checkpoint value for a Job finished at t: the latest
+// finish time reported so far, so the stored anchor never moves back.
+func (f *JobFetcher) position(t time.Time) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if t.After(f.latest) {
+		f.latest = t
+	}
+	if f.latest.IsZero() {
+		return ""
+	}
+	return f.latest.UTC().Format(time.RFC3339)
+}
+
+// jobOutcome returns the terminal Complete or Failed condition of a Job.
+func jobOutcome(job *batchv1.Job) (batchv1.JobCondition, bool) {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return c, true
+		}
+	}
+	return batchv1.JobCondition{}, false
+}
+
+// finishedAfter returns the terminal condition of a Job that finished at or
+// after t. Jobs without a known finish time are not reported.
+func finishedAfter(job *batchv1.Job, t time.Time) (batchv1.JobCondition, bool) {
+	cond, done := jobOutcome(job)
+	if !done {
+		return cond, false
+	}
+	finished := jobFinished(job, cond)
+	if finished.IsZero() || finished.Before(t) {
+		return cond, false
+	}
+	return cond, true
+}
+
+// jobFinished prefers the completion time and falls back to the time the
+// terminal condition was set; failed Jobs have no completion time.
+func jobFinished(job *batchv1.Job, cond batchv1.JobCondition) time.Time {
+	if job.Status.CompletionTime != nil {
+		return job.Status.CompletionTime.Time
+	}
+	return cond.LastTransitionTime.Time
+}
+
+func (f *JobFetcher) newEvent(job *batchv1.Job, cond batchv1.JobCondition) (*domain.Event, error) {
+	finished := jobFinished(job, cond)
+	if finished.IsZero() {
+		finished = time.Now().UTC()
+	}
+
+	eventType, reason := corev1.EventTypeNormal, "JobSucceeded"
+	msg := fmt.Sprintf("job %s succeeded (%d succeeded, %d failed)", job.Name, job.Status.Succeeded, job.Status.Failed)
+	if cond.Type == batchv1.JobFailed {
+		eventType, reason = corev1.EventTypeWarning, "JobFailed"
+		msg = fmt.Sprintf("job %s failed: %s (%d succeeded, %d failed)", job.Name, cond.Reason, job.Status.Succeeded, job.Status.Failed)
+		if cond.Message != "" {
+			msg += ": " + cond.Message
+		}
+	}
+
+	ev, err := domain.NewEvent(
+		fmt.Sprintf("%s/%s", job.UID, cond.Type),
+		job.Name,
+		job.Namespace,
+		reason,
+		msg,
+		eventType,
+		domain.ObjectRef{
+			Kind:       "Job",
+			Name:       job.Name,
+			Namespace:  job.Namespace,
+			UID:        string(job.UID),
+			APIVersion: batchv1.SchemeGroupVersion.String(),
+		},
+		sourceJob,
+		finished,
+		nil,
+		1,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ev.SetLogType(logTypeJob)
+	ev.SetClusterID(f.clusterID)
+	ev.SetAttribute("job.outcome", string(cond.Type))
+	ev.SetAttribute("job.succeeded", strconv.Itoa(int(job.Status.Succeeded)))
+	ev.SetAttribute("job.failed", strconv.Itoa(int(job.Status.Failed)))
+	if job.Spec.Completions != nil {
+		ev.SetAttribute("job.completions", strconv.Itoa(int(*job.Spec.Completions)))
+	}
+	if job.Status.StartTime != nil {
+		ev.SetAttribute("job.start_time", job.Status.StartTime.UTC().Format(time.RFC3339))
+		ev.SetAttribute("job.duration", finished.Sub(job.Status.StartTime.Time).Round(time.Second).String())
+	}
+	if cond.Type == batchv1.JobFailed {
+		if cond.Reason != "" {
+			ev.SetAttribute("job.failure_reason", cond.Reason)
+		}
+		if cond.Message != "" {
+			ev.SetAttribute("job.failure_message", cond.Message)
+		}
+	}
+	if owner := metav1.GetControllerOfNoCopy(job); owner != nil && owner.Kind == "CronJob" {
+		ev.SetAttribute("job.cronjob", owner.Name)
+	}
+	return ev, nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"event_exporter/internal/domain"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func finishedJob(name string, at time.Time) *batchv1.Job {
+	done := metav1.NewTime(at)
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID("uid-" + name), ResourceVersion: "1"},
+		Status: batchv1.JobStatus{
+			CompletionTime: &done,
+			Conditions: []batchv1.JobCondition{{
+				Type:               batchv1.JobComplete,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: done,
+			}},
+		},
+	}
+}
+
+func TestFinishedAfter(t *testing.T) {
+	started := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	job := func(typ batchv1.JobConditionType, transition time.Time, completion *time.Time) *batchv1.Job {
+		j := &batchv1.Job{
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{
+					Type:               typ,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(transition),
+				}},
+			},
+		}
+		if completion != nil {
+			at := metav1.NewTime(*completion)
+			j.Status.CompletionTime = &at
+		}
+		return j
+	}
+	before := started.Add(-time.Minute)
+	after := started.Add(time.Minute)
+
+	tests := []struct {
+		name string
+		job  *batchv1.Job
+		want bool
+	}{
+		{"running job", &batchv1.Job{}, false},
+		{"completed before the anchor", job(batchv1.JobComplete, before, &before), false},
+		{"completed after the anchor", job(batchv1.JobComplete, after, &after), true},
+		{"completed in the anchor second", job(batchv1.JobComplete, started, &started), true},
+		{"failed after the anchor", job(batchv1.JobFailed, after, nil), true},
+		{"failed before the anchor", job(batchv1.JobFailed, before, nil), false},
+		{"no finish time", job(batchv1.JobFailed, time.Time{}, nil), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := finishedAfter(tt.job, started); got != tt.want {
+				t.Fatalf("finishedAfter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobFetcherReportsJobsFinishedWhileDown(t *testing.T) {
+	anchor := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	downtime := anchor.Add(30 * time.Minute)
+
+	client := fake.NewClientset(
+		finishedJob("reported-before", anchor.Add(-time.Hour)),
+		finishedJob("finished-while-down", downtime),
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "default", UID: "uid-running"}},
+	)
+	store := &memStore{}
+	f := NewJobFetcher(nopLogger{}, FetcherConfig{
+		Checkpoint:         store,
+		CheckpointInterval: time.Hour,
+		Positions:          map[string]string{jobsCheckpointKey: anchor.Format(time.RFC3339)},
+	}, 0, client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := make(chan *domain.Event, 10)
+	go func() { _ = f.Stream(ctx, out) }()
+
+	var ev *domain.Event
+	select {
+	case ev = <-out:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no job reported")
+	}
+	if ev.Name() != "finished-while-down" {
+		t.Fatalf("reported %q, want finished-while-down", ev.Name())
+	}
+
+	for !f.Ready() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case ev := <-out:
+		t.Fatalf("unexpected report of %q", ev.Name())
+	default:
+	}
+
+	ev.Delivered()
+	f.FlushCheckpoint(context.Background())
+	if got, want := store.saved[jobsCheckpointKey], downtime.Format(time.RFC3339); got != want {
+		t.Fatalf("checkpoint = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"event_exporter/internal/domain"
+	"fmt"
+
+	"k8s.io/client-go/discovery"
+)
+
+// LabelEnricher copies allow-listed labels and annotations of the involved
+// object onto the entry as k8s.label.<key> / k8s.annotation.<key>.
+type LabelEnricher struct {
+	clusterID   string
+	cache       *MetadataCache
+	kinds       map[string]kindResource
+	labels      []string
+	annotations []string
+}
+
+// NewLabelEnricher resolves kinds through discovery and registers a metadata
+// informer for each of them on cache.
+func NewLabelEnricher(
+	ctx context.Context,
+	logger Logger,
+	clusterID string,
+	cache *MetadataCache,
+	dc discovery.DiscoveryInterface,
+	kinds []string,
+	labels []string,
+	annotations []string,
+) (*LabelEnricher, error) {
+	resolved, err := resolveKinds(dc, kinds)
+	if err != nil {
+		return nil, fmt.Errorf("adapters:kubernetes:labels: failed to resolve kinds: %w", err)
+	}
+
+	for _, kind := range kinds {
+		r, ok := resolved[kind]
+		if !ok {
+			logger.Warn(ctx, "adapters:kubernetes:labels: kind is not served by the cluster; skipping", "cluster", clusterID, "kind", kind)
+			continue
+		}
+		// namespaced RBAC cannot list cluster-scoped objects, and the cache
+		// would never sync
+		if !r.namespaced && len(cache.namespaces) > 0 {
+			logger.Warn(ctx, "adapters:kubernetes:labels: cluster-scoped kind is not looked up when include_namespaces is set", "cluster", clusterID, "kind", kind)
+			delete(resolved, kind)
+			continue
+		}
+		cache.Watch(r.gvr, r.namespaced)
+	}
+
+	return &LabelEnricher{
+		clusterID:   clusterID,
+		cache:       cache,
+		kinds:       resolved,
+		labels:      labels,
+		annotations: annotations,
+	}, nil
+}
+
+func (e *LabelEnricher) Enrich(_ context.Context, ev *domain.Event, fields map[string]string) {
+	if ev.ClusterID() != e.clusterID {
+		return
+	}
+
+	obj := ev.Object()
+	r, ok := e.kinds[obj.Kind]
+	if !ok {
+		return
+	}
+
+	namespace := obj.Namespace
+	if !r.namespaced {
+		namespace = ""
+	}
+
+	meta, ok := e.cache.Get(r.gvr, namespace, obj.Name)
+	if !ok {
+		return
+	}
+
+	for _, key := range e.labels {
+		if v, ok := meta.Labels[key]; ok {
+			fields["k8s.label."+key] = v
+		}
+	}
+	for _, key := range e.annotations {
+		if v, ok := meta.Annotations[key]; ok {
+			fields["k8s.annotation."+key] = v
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/metadata/metadatalister"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	gvrPods        = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	gvrReplicaSets = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+	gvrJobs        = schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+)
+
+// MetadataCache keeps metadata-only informers (labels, annotations, owners)
+// that enrichers look objects up in. With namespaces set, namespaced
+// resources are watched per namespace so namespaced RBAC is enough.
+type MetadataCache struct {
+	client     metadata.Interface
+	resync     time.Duration
+	namespaces []string
+	logger     Logger
+
+	mu      sync.Mutex
+	entries map[schema.GroupVersionResource]*metadataEntry
+}
+
+type metadataEntry struct {
+	namespaced bool
+	scopes     map[string]metadataScope
+}
+
+type metadataScope struct {
+	informer cache.SharedIndexInformer
+	lister   metadatalister.Lister
+}
+
+func NewMetadataCache(client metadata.Interface, namespaces []string, resync time.Duration, logger Logger) *MetadataCache {
+	return &MetadataCache{
+		client:     client,
+		resync:     resync,
+		namespaces: namespaces,
+		logger:     logger,
+		entries:    make(map[schema.GroupVersionResource]*metadataEntry),
+	}
+}
+
+// Watch registers gvr; it is picked up by the next Start.
+func (c *MetadataCache) Watch(gvr schema.GroupVersionResource, namespaced bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[gvr]; ok {
+		return
+	}
+
+	scopes := []string{metav1.NamespaceAll}
+	if namespaced && len(c.namespaces) > 0 {
+		scopes = c.namespaces
+	}
+
+	entry := &metadataEntry{
+		namespaced: namespaced,
+		scopes:     make(map[string]metadataScope, len(scopes)),
+	}
+	for _, ns := range scopes {
+		informer := metadatainformer.NewFilteredMetadataInformer(c.client, gvr, ns, c.resync, cache.Indexers{
+			cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+		}, nil).Informer()
+
+		entry.scopes[ns] = metadataScope{
+			informer: informer,
+			lister:   metadatalister.New(informer.GetIndexer(), gvr),
+		}
+	}
+
+	c.entries[gvr] = entry
+}
+
+// Start runs all registered informers and blocks until their caches synced.
+func (c *MetadataCache) Start(ctx context.Context) bool {
+	c.mu.Lock()
+	var synced []cache.InformerSynced
+	for _, entry := range c.entries {
+		for _, scope := range entry.scopes {
+			go scope.informer.RunWithContext(ctx)
+			synced = append(synced, scope.informer.HasSynced)
+		}
+	}
+	c.mu.Unlock()
+
+	c.logger.Info(ctx, "adapters:kubernetes:metadata: waiting for cache sync", "informers", len(synced))
+	return cache.WaitForCacheSync(ctx.Done(), synced...)
+}
+
+// Get returns the cached metadata of an object. Cluster-scoped objects are
+// looked up with an empty namespace.
+func (c *MetadataCache) Get(gvr schema.GroupVersionResource, namespace, name string) (*metav1.PartialObjectMetadata, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[gvr]
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	if !entry.namespaced {
+		obj, err := entry.scopes[metav1.NamespaceAll].lister.Get(name)
+		return obj, err == nil
+	}
+
+	scope, ok := entry.scopes[metav1.NamespaceAll]
+	if !ok {
+		scope, ok = entry.scopes[namespace]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	obj, err := scope.lister.Namespace(namespace).Get(name)
+	return obj, err == nil
+}
+
+// kindResource is the resource a Kind is served as.
+type kindResource struct {
+	gvr        schema.GroupVersionResource
+	namespaced bool
+}
+
+// resolveKinds maps Kinds to their preferred resources via discovery. When a
+// Kind exists in several groups the core group wins, otherwise the first one
+// discovery reports. Kinds the server does not know are left out.
+func resolveKinds(dc discovery.DiscoveryInterface, kinds []string) (map[string]kindResource, error) {
+	lists, err := dc.ServerPreferredResources()
+	if err != nil && len(lists) == 0 {
+		return nil, err
+	}
+
+	wanted := toSet(kinds)
+	resolved := make(map[string]kindResource, len(kinds))
+
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if _, ok := wanted[r.Kind]; !ok {
+				continue
+			}
+			if existing, ok := resolved[r.Kind]; ok && (existing.gvr.Group == "" || gv.Group != "") {
+				continue
+			}
+			resolved[r.Kind] = kindResource{
+				gvr:        gv.WithResource(r.Name),
+				namespaced: r.Namespaced,
+			}
+		}
+	}
+
+	return resolved, nil
+}
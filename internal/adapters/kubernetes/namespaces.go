@@ -0,0 +1,118 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"event_exporter/internal/domain"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var gvrNamespaces = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+// NamespaceFilter matches the namespace of an event against label selectors,
+// looking the namespace up in the metadata cache. A namespace missing from
+// the cache is treated as having no labels.
+type NamespaceFilter struct {
+	cache   *MetadataCache
+	include labels.Selector
+	exclude labels.Selector
+}
+
+// NewNamespaceFilter returns nil when both selectors are empty. Namespaces
+// are cluster-scoped, so the filter needs list/watch on namespaces.
+func NewNamespaceFilter(cache *MetadataCache, include, exclude string) (*NamespaceFilter, error) {
+	if include == "" && exclude == "" {
+		return nil, nil
+	}
+
+	f := &NamespaceFilter{cache: cache}
+
+	if include != "" {
+		sel, err := labels.Parse(include)
+		if err != nil {
+			return nil, fmt.Errorf("adapters:kubernetes:namespaces: invalid include selector %q: %w", include, err)
+		}
+		f.include = sel
+	}
+	if exclude != "" {
+		sel, err := labels.Parse(exclude)
+		if err != nil {
+			return nil, fmt.Errorf("adapters:kubernetes:namespaces: invalid exclude selector %q: %w", exclude, err)
+		}
+		f.exclude = sel
+	}
+
+	cache.Watch(gvrNamespaces, false)
+	return f, nil
+}
+
+// Allowed reports whether events of ns pass the selectors. Events without a
+// namespace always pass. A nil filter allows everything.
+func (f *NamespaceFilter) Allowed(ns string) bool {
+	if f == nil || ns == "" {
+		return true
+	}
+
+	var set labels.Set
+	if meta, ok := f.cache.Get(gvrNamespaces, "", ns); ok {
+		set = meta.Labels
+	}
+
+	if f.include != nil && !f.include.Matches(set) {
+		return false
+	}
+	if f.exclude != nil && f.exclude.Matches(set) {
+		return false
+	}
+	return true
+}
+
+// NamespaceEnricher copies allow-listed labels and annotations of the event's
+// namespace as k8s.namespace.label.<key> / k8s.namespace.annotation.<key>.
+type NamespaceEnricher struct {
+	clusterID   string
+	cache       *MetadataCache
+	labels      []string
+	annotations []string
+}
+
+// NewNamespaceEnricher registers the namespace informer on cache.
+func NewNamespaceEnricher(clusterID string, cache *MetadataCache, labels, annotations []string) *NamespaceEnricher {
+	cache.Watch(gvrNamespaces, false)
+	return &NamespaceEnricher{
+		clusterID:   clusterID,
+		cache:       cache,
+		labels:      labels,
+		annotations: annotations,
+	}
+}
+
+func (e *NamespaceEnricher) Enrich(_ context.Context, ev *domain.Event, fields map[string]string) {
+	if ev.ClusterID() != e.clusterID || ev.Namespace() == "" {
+		return
+	}
+
+	meta, ok := e.cache.Get(gvrNamespaces, "", ev.Namespace())
+	if !ok {
+		return
+	}
+
+	for _, key := range e.labels {
+		if v, ok := meta.Labels[key]; ok {
+			fields["k8s.namespace.label."+key] = v
+		}
+	}
+	for _, key := range e.annotations {
+		if v, ok := meta.Annotations[key]; ok {
+			fields["k8s.namespace.annotation."+key] = v
+		}
+	}
+}
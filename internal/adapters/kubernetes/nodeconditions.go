@@ -0,0 +1,243 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"event_exporter/internal/domain"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	logTypeNode = "node"
+	sourceNode  = "kent-node"
+)
+
+// watchedNodeConditions are reported when their status flips. The healthy
+// status of each is listed, anything else is reported as a warning.
+var watchedNodeConditions = map[corev1.NodeConditionType]corev1.ConditionStatus{
+	corev1.NodeReady:              corev1.ConditionTrue,
+	corev1.NodeMemoryPressure:     corev1.ConditionFalse,
+	corev1.NodeDiskPressure:       corev1.ConditionFalse,
+	corev1.NodePIDPressure:        corev1.ConditionFalse,
+	corev1.NodeNetworkUnavailable: corev1.ConditionFalse,
+}
+
+// NodeConditionFetcher watches Nodes and emits an event of log type "node"
+// whenever a watched condition changes status, a taint is added or removed,
+// or the node is cordoned or uncordoned.
+type NodeConditionFetcher struct {
+	client    kubernetes.Interface
+	logger    Logger
+	resync    time.Duration
+	clusterID string
+	ready     atomic.Bool
+}
+
+func NewNodeConditionFetcher(logger Logger, clusterID string, resync time.Duration, client kubernetes.Interface) *NodeConditionFetcher {
+	return &NodeConditionFetcher{
+		client:    client,
+		logger:    logger,
+		resync:    resync,
+		clusterID: clusterID,
+	}
+}
+
+// Ready reports true once the node cache synced.
+func (f *NodeConditionFetcher) Ready() bool {
+	return f.ready.Load()
+}
+
+func (f *NodeConditionFetcher) Stream(ctx context.Context, out chan<- *domain.Event) error {
+	defer f.ready.Store(false)
+
+	factory := informers.NewSharedInformerFactory(f.client, f.resync)
+	informer := factory.Core().V1().Nodes().Informer()
+
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj any) {
+			oldNode, ok1 := oldObj.(*corev1.Node)
+			newNode, ok2 := newObj.(*corev1.Node)
+			if !ok1 || !ok2 || oldNode.ResourceVersion == newNode.ResourceVersion {
+				return
+			}
+			for _, ev := range f.transitions(ctx, oldNode, newNode) {
+				select {
+				case <-ctx.Done():
+					return
+				case out <- ev:
+				}
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("adapters:kubernetes:nodeconditions: failed to register handler: %w", err)
+	}
+
+	factory.Start(ctx.Done())
+	defer factory.Shutdown()
+
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return ctx.Err()
+	}
+	f.ready.Store(true)
+	f.logger.Info(ctx, "adapters:kubernetes:nodeconditions: node cache synced", "cluster", f.clusterID)
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (f *NodeConditionFetcher) transitions(ctx context.Context, oldNode, newNode *corev1.Node) []*domain.Event {
+	var events []*domain.Event
+	add := func(ev *domain.Event, err error) *domain.Event {
+		if err != nil {
+			f.logger.Warn(ctx, "adapters:kubernetes:nodeconditions: failed to build event", "node", newNode.Name, "error", err)
+			return nil
+		}
+		events = append(events, ev)
+		return ev
+	}
+
+	previous := make(map[corev1.NodeConditionType]corev1.NodeCondition, len(oldNode.Status.Conditions))
+	for _, c := range oldNode.Status.Conditions {
+		previous[c.Type] = c
+	}
+	for _, c := range newNode.Status.Conditions {
+		healthy, watched := watchedNodeConditions[c.Type]
+		if !watched {
+			continue
+		}
+		prev, ok := previous[c.Type]
+		if ok && prev.Status == c.Status {
+			continue
+		}
+
+		eventType := corev1.EventTypeNormal
+		if c.Status != healthy {
+			eventType = corev1.EventTypeWarning
+		}
+		at := c.LastTransitionTime.Time
+		if at.IsZero() {
+			at = time.Now().UTC()
+		}
+		msg := fmt.Sprintf("node %s condition %s is %s", newNode.Name, c.Type, c.Status)
+		if c.Message != "" {
+			msg += ": " + c.Message
+		}
+		reason := "Node" + string(c.Type)
+		uid := fmt.Sprintf("%s/condition/%s/%s/%d", newNode.UID, c.Type, c.Status, at.Unix())
+
+		if ev := add(f.newEvent(newNode, uid, reason, eventType, msg, at)); ev != nil {
+			ev.SetAttribute("node.condition", string(c.Type))
+			ev.SetAttribute("node.condition_status", string(c.Status))
+			if ok {
+				ev.SetAttribute("node.condition_previous_status", string(prev.Status))
+			}
+			if c.Reason != "" {
+				ev.SetAttribute("node.condition_reason", c.Reason)
+			}
+		}
+	}
+
+	if oldNode.Spec.Unschedulable != newNode.Spec.Unschedulable {
+		reason, msg := "NodeUncordoned", fmt.Sprintf("node %s uncordoned", newNode.Name)
+		if newNode.Spec.Unschedulable {
+			reason, msg = "NodeCordoned", fmt.Sprintf("node %s cordoned", newNode.Name)
+		}
+		uid := fmt.Sprintf("%s/%s/%s", newNode.UID, reason, newNode.ResourceVersion)
+		if ev := add(f.newEvent(newNode, uid, reason, corev1.EventTypeNormal, msg, time.Now().UTC())); ev != nil {
+			ev.SetAttribute("node.unschedulable", strconv.FormatBool(newNode.Spec.Unschedulable))
+		}
+	}
+
+	oldTaints := taintSet(oldNode.Spec.Taints)
+	newTaints := taintSet(newNode.Spec.Taints)
+	for key, t := range newTaints {
+		if _, ok := oldTaints[key]; ok {
+			continue
+		}
+		msg := fmt.Sprintf("node %s tainted with %s", newNode.Name, key)
+		uid := fmt.Sprintf("%s/taint/%s/added/%s", newNode.UID, key, newNode.ResourceVersion)
+		if ev := add(f.newEvent(newNode, uid, "NodeTaintAdded", corev1.EventTypeWarning, msg, time.Now().UTC())); ev != nil {
+			setTaint(ev, t)
+		}
+	}
+	for key, t := range oldTaints {
+		if _, ok := newTaints[key]; ok {
+			continue
+		}
+		msg := fmt.Sprintf("node %s taint %s removed", newNode.Name, key)
+		uid := fmt.Sprintf("%s/taint/%s/removed/%s", newNode.UID, key, newNode.ResourceVersion)
+		if ev := add(f.newEvent(newNode, uid, "NodeTaintRemoved", corev1.EventTypeNormal, msg, time.Now().UTC())); ev != nil {
+			setTaint(ev, t)
+		}
+	}
+
+	return events
+}
+
+func (f *NodeConditionFetcher) newEvent(node *corev1.Node, uid, reason, eventType, msg string, at time.Time) (*domain.Event, error) {
+	ev, err := domain.NewEvent(
+		uid,
+		node.Name,
+		"",
+		reason,
+		msg,
+		eventType,
+		domain.ObjectRef{
+			Kind:       "Node",
+			Name:       node.Name,
+			UID:        string(node.UID),
+			APIVersion: "v1",
+		},
+		sourceNode,
+		at,
+		nil,
+		1,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ev.SetLogType(logTypeNode)
+	ev.SetClusterID(f.clusterID)
+	return ev, nil
+}
+
+// taintSet keys taints by key and effect; the value is part of the taint but
+// a changed value shows up as remove + add. The unschedulable taint mirrors
+// spec.unschedulable and is reported as cordon/uncordon instead.
+func taintSet(taints []corev1.Taint) map[string]corev1.Taint {
+	set := make(map[string]corev1.Taint, len(taints))
+	for _, t := range taints {
+		if t.Key == corev1.TaintNodeUnschedulable {
+			continue
+		}
+		key := t.Key
+		if t.Value != "" {
+			key += "=" + t.Value
+		}
+		set[key+":"+string(t.Effect)] = t
+	}
+	return set
+}
+
+func setTaint(ev *domain.Event, t corev1.Taint) {
+	ev.SetAttribute("node.taint_key", t.Key)
+	ev.SetAttribute("node.taint_effect", string(t.Effect))
+	if t.Value != "" {
+		ev.SetAttribute("node.taint_value", t.Value)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeTransitions(t *testing.T) {
+	unschedulable := corev1.Taint{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule}
+	gpu := corev1.Taint{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule}
+
+	node := func(cordoned bool, taints ...corev1.Taint) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "n1", UID: "uid", ResourceVersion: "2"},
+			Spec:       corev1.NodeSpec{Unschedulable: cordoned, Taints: taints},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		old, cur *corev1.Node
+		want     []string
+	}{
+		{"cordon is reported once", node(false), node(true, unschedulable), []string{"NodeCordoned"}},
+		{"uncordon is reported once", node(true, unschedulable), node(false), []string{"NodeUncordoned"}},
+		{"other taints are reported", node(false), node(false, gpu), []string{"NodeTaintAdded"}},
+		{"removed taint", node(false, gpu), node(false), []string{"NodeTaintRemoved"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewNodeConditionFetcher(nopLogger{}, "", 0, nil)
+			var got []string
+			for _, ev := range f.transitions(context.Background(), tt.old, tt.cur) {
+				got = append(got, ev.Reason())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("reasons = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
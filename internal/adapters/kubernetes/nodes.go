@@ -0,0 +1,158 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"event_exporter/internal/domain"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+var gvrNodes = schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+
+// nodeTopologyFields maps well-known node labels to the fields they are
+// exported as.
+var nodeTopologyFields = map[string]string{
+	corev1.LabelTopologyZone:       "k8s.node.zone",
+	corev1.LabelTopologyRegion:     "k8s.node.region",
+	corev1.LabelInstanceTypeStable: "k8s.node.instance_type",
+}
+
+// NodeEnricher adds the node a pod is scheduled on (k8s.node.name) and the
+// node's zone, region and instance type. Extra node labels, such as node
+// pool labels, are copied as k8s.node.label.<key>. Events about a Node are
+// enriched with that node.
+type NodeEnricher struct {
+	clusterID string
+	meta      *MetadataCache
+	labels    []string
+
+	factories []informers.SharedInformerFactory
+	pods      map[string]listersv1.PodLister
+	synced    []cache.InformerSynced
+}
+
+// NewNodeEnricher registers the node informer on meta and builds a pod
+// informer per namespace that keeps only spec.nodeName of each pod. With
+// namespaces set only the node name is added: Nodes are cluster-scoped and
+// namespaced RBAC cannot list them, so their cache would never sync.
+func NewNodeEnricher(
+	ctx context.Context,
+	logger Logger,
+	clusterID string,
+	meta *MetadataCache,
+	client kubernetes.Interface,
+	namespaces []string,
+	resync time.Duration,
+	labels []string,
+) *NodeEnricher {
+	if len(namespaces) > 0 {
+		logger.Warn(ctx, "adapters:kubernetes:nodes: node labels are not looked up when include_namespaces is set", "cluster", clusterID)
+	} else {
+		meta.Watch(gvrNodes, false)
+	}
+
+	e := &NodeEnricher{
+		clusterID: clusterID,
+		meta:      meta,
+		labels:    labels,
+		pods:      make(map[string]listersv1.PodLister),
+	}
+
+	for _, ns := range watchNamespaces(namespaces) {
+		factory := informers.NewSharedInformerFactoryWithOptions(client, resync,
+			informers.WithNamespace(ns),
+			informers.WithTransform(stripPod),
+		)
+		pods := factory.Core().V1().Pods()
+		e.synced = append(e.synced, pods.Informer().HasSynced)
+		e.pods[ns] = pods.Lister()
+		e.factories = append(e.factories, factory)
+	}
+
+	return e
+}
+
+// stripPod keeps only what the enricher reads, so the cache stays small.
+func stripPod(obj any) (any, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return obj, nil
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            pod.Name,
+			Namespace:       pod.Namespace,
+			UID:             pod.UID,
+			ResourceVersion: pod.ResourceVersion,
+		},
+		Spec: corev1.PodSpec{NodeName: pod.Spec.NodeName},
+	}, nil
+}
+
+// Start runs the pod informers and blocks until they synced. The node
+// informer is started with the metadata cache.
+func (e *NodeEnricher) Start(ctx context.Context) bool {
+	for _, factory := range e.factories {
+		factory.Start(ctx.Done())
+	}
+	return cache.WaitForCacheSync(ctx.Done(), e.synced...)
+}
+
+func (e *NodeEnricher) Enrich(_ context.Context, ev *domain.Event, fields map[string]string) {
+	if ev.ClusterID() != e.clusterID {
+		return
+	}
+
+	obj := ev.Object()
+
+	var nodeName string
+	switch obj.Kind {
+	case "Node":
+		nodeName = obj.Name
+	case "Pod":
+		lister, ok := e.pods[metav1.NamespaceAll]
+		if !ok {
+			lister, ok = e.pods[obj.Namespace]
+			if !ok {
+				return
+			}
+		}
+		pod, err := lister.Pods(obj.Namespace).Get(obj.Name)
+		if err != nil {
+			return
+		}
+		nodeName = pod.Spec.NodeName
+	}
+	if nodeName == "" {
+		return
+	}
+	fields["k8s.node.name"] = nodeName
+
+	node, ok := e.meta.Get(gvrNodes, "", nodeName)
+	if !ok {
+		return
+	}
+	for label, field := range nodeTopologyFields {
+		if v, ok := node.Labels[label]; ok {
+			fields[field] = v
+		}
+	}
+	for _, key := range e.labels {
+		if v, ok := node.Labels[key]; ok {
+			fields["k8s.node.label."+key] = v
+		}
+	}
+}
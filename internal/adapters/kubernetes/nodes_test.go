@@ -0,0 +1,36 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNodeEnricherWatchesNodesOnlyClusterWide(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespaces []string
+		want       bool
+	}{
+		{"cluster-wide", nil, true},
+		{"namespaced rbac", []string{"apps"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := NewMetadataCache(nil, tt.namespaces, 0, nopLogger{})
+			NewNodeEnricher(context.Background(), nopLogger{}, "", meta, fake.NewClientset(), tt.namespaces, 0, nil)
+
+			if _, got := meta.entries[gvrNodes]; got != tt.want {
+				t.Fatalf("node informer registered = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"event_exporter/internal/domain"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// maxOwnerDepth guards against cycles in broken ownerReferences.
+const maxOwnerDepth = 5
+
+// ownerKinds are the kinds whose ownerReferences are followed; anything
+// above them (Deployment, StatefulSet, DaemonSet, CronJob) is the top.
+var ownerKinds = map[string]schema.GroupVersionResource{
+	"Pod":        gvrPods,
+	"ReplicaSet": gvrReplicaSets,
+	"Job":        gvrJobs,
+}
+
+// workloadKinds are reported as their own workload when an event is about them.
+var workloadKinds = map[string]struct{}{
+	"Deployment":  {},
+	"StatefulSet": {},
+	"DaemonSet":   {},
+	"ReplicaSet":  {},
+	"Job":         {},
+	"CronJob":     {},
+}
+
+// OwnerEnricher resolves the involved object up its controller ownerReferences
+// (Pod → ReplicaSet → Deployment, Job → CronJob) and adds k8s.owner.* for the
+// direct owner and k8s.workload.* for the top of the chain.
+type OwnerEnricher struct {
+	clusterID string
+	cache     *MetadataCache
+}
+
+// NewOwnerEnricher registers the informers it needs on cache.
+func NewOwnerEnricher(clusterID string, cache *MetadataCache) *OwnerEnricher {
+	for _, gvr := range ownerKinds {
+		cache.Watch(gvr, true)
+	}
+	return &OwnerEnricher{
+		clusterID: clusterID,
+		cache:     cache,
+	}
+}
+
+func (e *OwnerEnricher) Enrich(_ context.Context, ev *domain.Event, fields map[string]string) {
+	if ev.ClusterID() != e.clusterID {
+		return
+	}
+
+	obj := ev.Object()
+	kind, name := obj.Kind, obj.Name
+
+	var owner *metav1.OwnerReference
+	for depth := 0; depth < maxOwnerDepth; depth++ {
+		gvr, ok := ownerKinds[kind]
+		if !ok {
+			break
+		}
+		meta, ok := e.cache.Get(gvr, obj.Namespace, name)
+		if !ok {
+			break
+		}
+		ref := metav1.GetControllerOfNoCopy(meta)
+		if ref == nil {
+			break
+		}
+		if owner == nil {
+			owner = ref
+			fields["k8s.owner.kind"] = ref.Kind
+			fields["k8s.owner.name"] = ref.Name
+		}
+		kind, name = ref.Kind, ref.Name
+	}
+
+	if owner == nil {
+		if _, ok := workloadKinds[obj.Kind]; !ok {
+			return
+		}
+	}
+
+	fields["k8s.workload.kind"] = kind
+	fields["k8s.workload.name"] = name
+}
@@ -0,0 +1,278 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"event_exporter/internal/domain"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	logTypePodStatus = "pod_status"
+	sourcePodStatus  = "kent-pod-status"
+	reasonRestarted  = "ContainerRestarted"
+	reasonTerminated = "ContainerTerminated"
+)
+
+// ignoredWaitingReasons are the waiting states every container passes through.
+var ignoredWaitingReasons = map[string]struct{}{
+	"ContainerCreating": {},
+	"PodInitializing":   {},
+}
+
+// PodStatusFetcher watches pods and turns container state transitions
+// (terminations, restarts, new waiting reasons such as CrashLoopBackOff)
+// into synthetic events of log type "pod_status". Only changes observed
+// after the initial sync are reported.
+type PodStatusFetcher struct {
+	client     kubernetes.Interface
+	logger     Logger
+	resync     time.Duration
+	clusterID  string
+	namespaces []string
+	includeNS  map[string]struct{}
+	excludeNS  map[string]struct{}
+	nsFilter   *NamespaceFilter
+	ready      atomic.Bool
+}
+
+func NewPodStatusFetcher(logger Logger, cfg FetcherConfig, resync time.Duration, client kubernetes.Interface) *PodStatusFetcher {
+	return &PodStatusFetcher{
+		client:     client,
+		logger:     logger,
+		resync:     resync,
+		clusterID:  cfg.ClusterID,
+		namespaces: watchNamespaces(cfg.IncludeNamespaces),
+		includeNS:  toSet(cfg.IncludeNamespaces),
+		excludeNS:  toSet(cfg.ExcludeNamespaces),
+		nsFilter:   cfg.NamespaceFilter,
+	}
+}
+
+// Ready reports true once the pod caches synced.
+func (f *PodStatusFetcher) Ready() bool {
+	return f.ready.Load()
+}
+
+func (f *PodStatusFetcher) Stream(ctx context.Context, out chan<- *domain.Event) error {
+	defer f.ready.Store(false)
+
+	handlers := cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj any) {
+			oldPod, ok1 := oldObj.(*corev1.Pod)
+			newPod, ok2 := newObj.(*corev1.Pod)
+			if !ok1 || !ok2 || oldPod.ResourceVersion == newPod.ResourceVersion {
+				return
+			}
+			for _, ev := range f.transitions(ctx, oldPod, newPod) {
+				select {
+				case <-ctx.Done():
+					return
+				case out <- ev:
+				}
+			}
+		},
+	}
+
+	var synced []cache.InformerSynced
+	for _, ns := range f.namespaces {
+		factory := informers.NewSharedInformerFactoryWithOptions(f.client, f.resync, informers.WithNamespace(ns))
+		informer := factory.Core().V1().Pods().Informer()
+		if _, err := informer.AddEventHandler(handlers); err != nil {
+			return fmt.Errorf("adapters:kubernetes:podstatus: failed to register handler: %w", err)
+		}
+		factory.Start(ctx.Done())
+		defer factory.Shutdown()
+		synced = append(synced, informer.HasSynced)
+	}
+
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		return ctx.Err()
+	}
+	f.ready.Store(true)
+	f.logger.Info(ctx, "adapters:kubernetes:podstatus: pod cache synced", "cluster", f.clusterID, "namespaces", f.namespaces)
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// transitions compares the container statuses of two versions of a pod.
+func (f *PodStatusFetcher) transitions(ctx context.Context, oldPod, newPod *corev1.Pod) []*domain.Event {
+	if !namespaceAllowed(f.includeNS, f.excludeNS, newPod.Namespace) || !f.nsFilter.Allowed(newPod.Namespace) {
+		return nil
+	}
+
+	var events []*domain.Event
+	compare := func(oldStatuses, newStatuses []corev1.ContainerStatus, init bool) {
+		previous := make(map[string]corev1.ContainerStatus, len(oldStatuses))
+		for _, s := range oldStatuses {
+			previous[s.Name] = s
+		}
+		for _, cur := range newStatuses {
+			events = append(events, f.containerTransitions(ctx, newPod, previous[cur.Name], cur, init)...)
+		}
+	}
+
+	compare(oldPod.Status.InitContainerStatuses, newPod.Status.InitContainerStatuses, true)
+	compare(oldPod.Status.ContainerStatuses, newPod.Status.ContainerStatuses, false)
+	return events
+}
+
+func (f *PodStatusFetcher) containerTransitions(ctx context.Context, pod *corev1.Pod, prev, cur corev1.ContainerStatus, init bool) []*domain.Event {
+	var events []*domain.Event
+	add := func(ev *domain.Event, err error) *domain.Event {
+		if err != nil {
+			f.logger.Warn(ctx, "adapters:kubernetes:podstatus: failed to build event", "pod", pod.Name, "container", cur.Name, "error", err)
+			return nil
+		}
+		events = append(events, ev)
+		return ev
+	}
+
+	if cur.RestartCount > prev.RestartCount {
+		last := cur.LastTerminationState.Terminated
+		msg := fmt.Sprintf("container %s restarted (restart count %d)", cur.Name, cur.RestartCount)
+		at := time.Now().UTC()
+		if last != nil {
+			msg = fmt.Sprintf("container %s restarted after %s (exit code %d, restart count %d)", cur.Name, last.Reason, last.ExitCode, cur.RestartCount)
+			if !last.FinishedAt.IsZero() {
+				at = last.FinishedAt.Time
+			}
+		}
+		if ev := add(f.newEvent(pod, cur, init, reasonRestarted, corev1.EventTypeWarning, msg, at)); ev != nil && last != nil {
+			setTerminated(ev, last)
+		}
+	}
+
+	// a container the kubelet restarts is reported once, by the restart
+	// record above with the termination from lastState; only terminations
+	// that are final get a record of their own
+	if t := cur.State.Terminated; t != nil && cur.RestartCount == prev.RestartCount &&
+		(prev.State.Terminated == nil || prev.State.Terminated.ContainerID != t.ContainerID) &&
+		!willRestart(pod, cur.Name, init, t) {
+		eventType := corev1.EventTypeWarning
+		if t.ExitCode == 0 {
+			eventType = corev1.EventTypeNormal
+		}
+		reason := t.Reason
+		if reason == "" {
+			reason = reasonTerminated
+		}
+		at := t.FinishedAt.Time
+		if at.IsZero() {
+			at = time.Now().UTC()
+		}
+		msg := fmt.Sprintf("container %s terminated: %s (exit code %d)", cur.Name, reason, t.ExitCode)
+		if ev := add(f.newEvent(pod, cur, init, reason, eventType, msg, at)); ev != nil {
+			setTerminated(ev, t)
+		}
+	}
+
+	if w := cur.State.Waiting; w != nil && w.Reason != "" &&
+		(prev.State.Waiting == nil || prev.State.Waiting.Reason != w.Reason) {
+		if _, ignored := ignoredWaitingReasons[w.Reason]; !ignored {
+			msg := fmt.Sprintf("container %s is waiting: %s", cur.Name, w.Reason)
+			if w.Message != "" {
+				msg += ": " + w.Message
+			}
+			if ev := add(f.newEvent(pod, cur, init, w.Reason, corev1.EventTypeWarning, msg, time.Now().UTC())); ev != nil {
+				ev.SetAttribute("container.waiting_reason", w.Reason)
+			}
+		}
+	}
+
+	return events
+}
+
+// willRestart reports whether the kubelet restarts a container that
+// terminated with t, following the container (sidecar) or pod restart policy.
+func willRestart(pod *corev1.Pod, name string, init bool, t *corev1.ContainerStateTerminated) bool {
+	if pod.DeletionTimestamp != nil {
+		return false
+	}
+	if init {
+		for _, c := range pod.Spec.InitContainers {
+			if c.Name == name && c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+				return true
+			}
+		}
+		return t.ExitCode != 0 && pod.Spec.RestartPolicy != corev1.RestartPolicyNever
+	}
+
+	switch pod.Spec.RestartPolicy {
+	case corev1.RestartPolicyNever:
+		return false
+	case corev1.RestartPolicyOnFailure:
+		return t.ExitCode != 0
+	default:
+		return true
+	}
+}
+
+func (f *PodStatusFetcher) newEvent(pod *corev1.Pod, cs corev1.ContainerStatus, init bool, reason, eventType, msg string, at time.Time) (*domain.Event, error) {
+	fieldPath := fmt.Sprintf("spec.containers{%s}", cs.Name)
+	if init {
+		fieldPath = fmt.Sprintf("spec.initContainers{%s}", cs.Name)
+	}
+
+	// stable per transition, so resyncs and dedup do not double-report it
+	uid := fmt.Sprintf("%s/%s/%d/%s", pod.UID, cs.Name, cs.RestartCount, reason)
+
+	ev, err := domain.NewEvent(
+		uid,
+		pod.Name,
+		pod.Namespace,
+		reason,
+		msg,
+		eventType,
+		domain.ObjectRef{
+			Kind:       "Pod",
+			Name:       pod.Name,
+			Namespace:  pod.Namespace,
+			UID:        string(pod.UID),
+			APIVersion: "v1",
+			FieldPath:  fieldPath,
+		},
+		sourcePodStatus,
+		at,
+		nil,
+		1,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ev.SetLogType(logTypePodStatus)
+	ev.SetClusterID(f.clusterID)
+	ev.SetDetails(domain.EventDetails{SourceHost: pod.Spec.NodeName})
+	ev.SetAttribute("container.name", cs.Name)
+	ev.SetAttribute("container.init", strconv.FormatBool(init))
+	ev.SetAttribute("container.restart_count", strconv.Itoa(int(cs.RestartCount)))
+	return ev, nil
+}
+
+func setTerminated(ev *domain.Event, t *corev1.ContainerStateTerminated) {
+	ev.SetAttribute("container.exit_code", strconv.Itoa(int(t.ExitCode)))
+	if t.Reason != "" {
+		ev.SetAttribute("container.termination_reason", t.Reason)
+	}
+	if t.Signal != 0 {
+		ev.SetAttribute("container.signal", strconv.Itoa(int(t.Signal)))
+	}
+	if !t.StartedAt.IsZero() && !t.FinishedAt.IsZero() {
+		ev.SetAttribute("container.runtime", t.FinishedAt.Sub(t.StartedAt.Time).String())
+	}
+}
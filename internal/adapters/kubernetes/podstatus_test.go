@@ -0,0 +1,65 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func podWith(policy corev1.RestartPolicy, cs corev1.ContainerStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "ns", UID: "uid"},
+		Spec:       corev1.PodSpec{RestartPolicy: policy},
+		Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{cs}},
+	}
+}
+
+func terminated(id string, code int32) *corev1.ContainerStateTerminated {
+	return &corev1.ContainerStateTerminated{ContainerID: id, ExitCode: code, Reason: "Error"}
+}
+
+func TestPodStatusCrashIsReportedOnce(t *testing.T) {
+	running := corev1.ContainerStatus{Name: "app", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}
+	crashed := corev1.ContainerStatus{Name: "app", State: corev1.ContainerState{Terminated: terminated("c1", 1)}}
+	restarted := corev1.ContainerStatus{
+		Name:                 "app",
+		RestartCount:         1,
+		State:                corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		LastTerminationState: corev1.ContainerState{Terminated: terminated("c1", 1)},
+	}
+
+	tests := []struct {
+		name   string
+		policy corev1.RestartPolicy
+		steps  []corev1.ContainerStatus
+		want   []string
+	}{
+		{"always restarts", corev1.RestartPolicyAlways, []corev1.ContainerStatus{running, crashed, restarted}, []string{reasonRestarted}},
+		{"on failure restarts", corev1.RestartPolicyOnFailure, []corev1.ContainerStatus{running, crashed, restarted}, []string{reasonRestarted}},
+		{"never is final", corev1.RestartPolicyNever, []corev1.ContainerStatus{running, crashed}, []string{"Error"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewPodStatusFetcher(nopLogger{}, FetcherConfig{}, 0, nil)
+			var got []string
+			for i := 1; i < len(tt.steps); i++ {
+				for _, ev := range f.transitions(context.Background(), podWith(tt.policy, tt.steps[i-1]), podWith(tt.policy, tt.steps[i])) {
+					got = append(got, ev.Reason())
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("reasons = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"event_exporter/internal/domain"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	logTypeRollout = "rollout"
+	sourceRollout  = "kent-rollouts"
+
+	rolloutStarted     = "started"
+	rolloutProgressing = "progressing"
+	rolloutCompleted   = "completed"
+	rolloutFailed      = "failed"
+)
+
+// rolloutState is what KENT compares between two versions of a workload.
+type rolloutState struct {
+	object     metav1.Object
+	kind       string
+	revision   string
+	desired    int32
+	updated    int32
+	ready      int32
+	available  int32
+	complete   bool
+	failed     bool
+	failReason string
+	images     []string
+}
+
+// RolloutFetcher watches Deployments, StatefulSets and DaemonSets and emits
+// log type "rollout" records when a new revision starts rolling out, makes
+// progress, completes or fails.
+type RolloutFetcher struct {
+	client     kubernetes.Interface
+	logger     Logger
+	resync     time.Duration
+	clusterID  string
+	namespaces []string
+	includeNS  map[string]struct{}
+	excludeNS  map[string]struct{}
+	nsFilter   *NamespaceFilter
+	ready      atomic.Bool
+
+	mu      sync.Mutex
+	started map[types.UID]time.Time
+}
+
+func NewRolloutFetcher(logger Logger, cfg FetcherConfig, resync time.Duration, client kubernetes.Interface) *RolloutFetcher {
+	return &RolloutFetcher{
+		client:     client,
+		logger:     logger,
+		resync:     resync,
+		clusterID:  cfg.ClusterID,
+		namespaces: watchNamespaces(cfg.IncludeNamespaces),
+		includeNS:  toSet(cfg.IncludeNamespaces),
+		excludeNS:  toSet(cfg.ExcludeNamespaces),
+		nsFilter:   cfg.NamespaceFilter,
+		started:    make(map[types.UID]time.Time),
+	}
+}
+
+// Ready reports true once the workload caches synced.
+func (f *RolloutFetcher) Ready() bool {
+	return f.ready.Load()
+}
+
+func (f *RolloutFetcher) Stream(ctx context.Context, out chan<- *domain.Event) error {
+	defer f.ready.Store(false)
+
+	handlers := cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj any) {
+			if resourceVersionOf(oldObj) == resourceVersionOf(newObj) {
+				return
+			}
+			oldState, ok1 := rolloutStateOf(oldObj)
+			newState, ok2 := rolloutStateOf(newObj)
+			if !ok1 || !ok2 {
+				return
+			}
+			for _, ev := range f.transitions(ctx, oldState, newState) {
+				select {
+				case <-ctx.Done():
+					return
+				case out <- ev:
+				}
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if m, err := meta.Accessor(obj); err == nil {
+				f.mu.Lock()
+				delete(f.started, m.GetUID())
+				f.mu.Unlock()
+			}
+		},
+	}
+
+	var synced []cache.InformerSynced
+	for _, ns := range f.namespaces {
+		factory := informers.NewSharedInformerFactoryWithOptions(f.client, f.resync, informers.WithNamespace(ns))
+		for _, informer := range []cache.SharedIndexInformer{
+			factory.Apps().V1().Deployments().Informer(),
+			factory.Apps().V1().StatefulSets().Informer(),
+			factory.Apps().V1().DaemonSets().Informer(),
+		} {
+			if _, err := informer.AddEventHandler(handlers); err != nil {
+				return fmt.Errorf("adapters:kubernetes:rollouts: failed to register handler: %w", err)
+			}
+			synced = append(synced, informer.HasSynced)
+		}
+		factory.Start(ctx.Done())
+		defer factory.Shutdown()
+	}
+
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		return ctx.Err()
+	}
+	f.ready.Store(true)
+	f.logger.Info(ctx, "adapters:kubernetes:rollouts: workload caches synced", "cluster", f.clusterID, "namespaces", f.namespaces)
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func rolloutStateOf(obj any) (rolloutState, bool) {
+	switch w := obj.(type) {
+	case *appsv1.Deployment:
+		desired := replicasOrOne(w.Spec.Replicas)
+		st := rolloutState{
+			object:    w,
+			kind:      "Deployment",
+			revision:  w.Annotations["deployment.kubernetes.io/revision"],
+			desired:   desired,
+			updated:   w.Status.UpdatedReplicas,
+			ready:     w.Status.ReadyReplicas,
+			available: w.Status.AvailableReplicas,
+			images:    imagesOf(w.Spec.Template.Spec),
+		}
+		st.complete = w.Status.ObservedGeneration >= w.Generation &&
+			w.Status.UpdatedReplicas == desired &&
+			w.Status.Replicas == desired &&
+			w.Status.AvailableReplicas == desired
+		for _, c := range w.Status.Conditions {
+			if c.Type == appsv1.DeploymentProgressing && c.Status == corev1.ConditionFalse {
+				st.failed, st.failReason = true, c.Reason
+			}
+		}
+		return st, true
+
+	case *appsv1.StatefulSet:
+		desired := replicasOrOne(w.Spec.Replicas)
+		return rolloutState{
+			object:    w,
+			kind:      "StatefulSet",
+			revision:  w.Status.UpdateRevision,
+			desired:   desired,
+			updated:   w.Status.UpdatedReplicas,
+			ready:     w.Status.ReadyReplicas,
+			available: w.Status.AvailableReplicas,
+			images:    imagesOf(w.Spec.Template.Spec),
+			complete: w.Status.ObservedGeneration >= w.Generation &&
+				w.Status.UpdatedReplicas == desired &&
+				w.Status.ReadyReplicas == desired &&
+				w.Status.CurrentRevision == w.Status.UpdateRevision,
+		}, true
+
+	case *appsv1.DaemonSet:
+		desired := w.Status.DesiredNumberScheduled
+		return rolloutState{
+			object:    w,
+			kind:      "DaemonSet",
+			revision:  daemonSetRevision(w),
+			desired:   desired,
+			updated:   w.Status.UpdatedNumberScheduled,
+			ready:     w.Status.NumberReady,
+			available: w.Status.NumberAvailable,
+			images:    imagesOf(w.Spec.Template.Spec),
+			complete: w.Status.ObservedGeneration >= w.Generation &&
+				w.Status.UpdatedNumberScheduled == desired &&
+				w.Status.NumberAvailable == desired,
+		}, true
+	}
+	return rolloutState{}, false
+}
+
+// daemonSetRevision changes only when the pod template does. The API server
+// bumps the template generation annotation on template edits, while
+// metadata.generation also moves for updateStrategy and other spec changes
+// that do not roll pods. Objects without the annotation fall back to a hash
+// of the template.
+func daemonSetRevision(ds *appsv1.DaemonSet) string {
+	if gen := ds.Annotations[appsv1.DeprecatedTemplateGeneration]; gen != "" {
+		return gen
+	}
+	data, err := json.Marshal(ds.Spec.Template)
+	if err != nil {
+		return strconv.FormatInt(ds.Generation, 10)
+	}
+	h := fnv.New32a()
+	h.Write(data)
+	return strconv.FormatUint(uint64(h.Sum32()), 16)
+}
+
+func replicasOrOne(r *int32) int32 {
+	if r == nil {
+		return 1
+	}
+	return *r
+}
+
+func imagesOf(spec corev1.PodSpec) []string {
+	images := make([]string, 0, len(spec.Containers))
+	for _, c := range spec.Containers {
+		images = append(images, c.Image)
+	}
+	return images
+}
+
+func (f *RolloutFetcher) transitions(ctx context.Context, oldState, newState rolloutState) []*domain.Event {
+	ns := newState.object.GetNamespace()
+	if !namespaceAllowed(f.includeNS, f.excludeNS, ns) || !f.nsFilter.Allowed(ns) {
+		return nil
+	}
+	uid := newState.object.GetUID()
+	now := time.Now().UTC()
+
+	// progress and completion are only reported for revisions KENT saw
+	// start, so scaling a workload does not look like a rollout. The first
+	// revision of a new workload counts as a start while its pods are
+	// still being created.
+	started := newState.revision != oldState.revision &&
+		(oldState.revision != "" || newState.updated < newState.desired)
+	f.mu.Lock()
+	_, tracked := f.started[uid]
+	if started {
+		f.started[uid] = now
+		tracked = true
+	}
+	f.mu.Unlock()
+
+	var phases []string
+	switch {
+	case started:
+		phases = append(phases, rolloutStarted)
+		if newState.complete {
+			phases = append(phases, rolloutCompleted)
+		}
+	case newState.failed && !oldState.failed:
+		phases = append(phases, rolloutFailed)
+	case tracked && newState.complete:
+		phases = append(phases, rolloutCompleted)
+	case tracked && newState.updated != oldState.updated:
+		phases = append(phases, rolloutProgressing)
+	}
+
+	var events []*domain.Event
+	for _, phase := range phases {
+		ev, err := f.newEvent(newState, oldState.revision, phase, now)
+		if err != nil {
+			f.logger.Warn(ctx, "adapters:kubernetes:rollouts: failed to build event",
+				"kind", newState.kind,
+				"name", newState.object.GetName(),
+				"error", err,
+			)
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+func (f *RolloutFetcher) newEvent(st rolloutState, previousRevision, phase string, at time.Time) (*domain.Event, error) {
+	obj := st.object
+
+	eventType := corev1.EventTypeNormal
+	msg := fmt.Sprintf("%s %s rollout of revision %s %s (%d/%d updated, %d available)",
+		st.kind, obj.GetName(), st.revision, phase, st.updated, st.desired, st.available)
+	if phase == rolloutFailed {
+		eventType = corev1.EventTypeWarning
+		msg += ": " + st.failReason
+	}
+
+	reason := "Rollout" + strings.ToUpper(phase[:1]) + phase[1:]
+	uid := fmt.Sprintf("%s/%s/%s", obj.GetUID(), st.revision, phase)
+	if phase == rolloutProgressing {
+		uid += "/" + strconv.Itoa(int(st.updated))
+	}
+
+	ev, err := domain.NewEvent(
+		uid,
+		obj.GetName(),
+		obj.GetNamespace(),
+		reason,
+		msg,
+		eventType,
+		domain.ObjectRef{
+			Kind:       st.kind,
+			Name:       obj.GetName(),
+			Namespace:  obj.GetNamespace(),
+			UID:        string(obj.GetUID()),
+			APIVersion: appsv1.SchemeGroupVersion.String(),
+		},
+		sourceRollout,
+		at,
+		nil,
+		1,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ev.SetLogType(logTypeRollout)
+	ev.SetClusterID(f.clusterID)
+	ev.SetAttribute("rollout.phase", phase)
+	ev.SetAttribute("rollout.revision", st.revision)
+	if previousRevision != "" && previousRevision != st.revision {
+		ev.SetAttribute("rollout.previous_revision", previousRevision)
+	}
+	ev.SetAttribute("rollout.desired", strconv.Itoa(int(st.desired)))
+	ev.SetAttribute("rollout.updated", strconv.Itoa(int(st.updated)))
+	ev.SetAttribute("rollout.ready", strconv.Itoa(int(st.ready)))
+	ev.SetAttribute("rollout.available", strconv.Itoa(int(st.available)))
+	ev.SetAttribute("rollout.images", strings.Join(st.images, ","))
+	if st.failReason != "" {
+		ev.SetAttribute("rollout.fail_reason", st.failReason)
+	}
+
+	if phase == rolloutCompleted || phase == rolloutFailed {
+		f.mu.Lock()
+		startedAt, ok := f.started[obj.GetUID()]
+		delete(f.started, obj.GetUID())
+		f.mu.Unlock()
+		if ok {
+			ev.SetAttribute("rollout.duration", at.Sub(startedAt).Round(time.Second).String())
+		}
+	}
+	return ev, nil
+}
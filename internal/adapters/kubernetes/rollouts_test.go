@@ -0,0 +1,121 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func deploymentAt(revision string, replicas, updated int32) *appsv1.Deployment {
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "apps", UID: "d-1", Generation: 1},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 1,
+			Replicas:           replicas,
+			UpdatedReplicas:    updated,
+			ReadyReplicas:      updated,
+			AvailableReplicas:  updated,
+		},
+	}
+	if revision != "" {
+		d.Annotations = map[string]string{"deployment.kubernetes.io/revision": revision}
+	}
+	return d
+}
+
+func daemonSetAt(generation int64, templateGeneration string, updated int32) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "agent",
+			Namespace:   "apps",
+			UID:         "ds-1",
+			Generation:  generation,
+			Annotations: map[string]string{appsv1.DeprecatedTemplateGeneration: templateGeneration},
+		},
+		Status: appsv1.DaemonSetStatus{
+			ObservedGeneration:     generation,
+			DesiredNumberScheduled: 3,
+			UpdatedNumberScheduled: updated,
+			NumberReady:            updated,
+			NumberAvailable:        updated,
+		},
+	}
+}
+
+func TestRolloutTransitions(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []any
+		want  []string
+	}{
+		{
+			name:  "first revision of a new deployment",
+			steps: []any{deploymentAt("", 3, 0), deploymentAt("1", 3, 0), deploymentAt("1", 3, 2), deploymentAt("1", 3, 3)},
+			want:  []string{rolloutStarted, rolloutProgressing, rolloutCompleted},
+		},
+		{
+			name:  "first revision already rolled out",
+			steps: []any{deploymentAt("", 3, 3), deploymentAt("1", 3, 3)},
+		},
+		{
+			name:  "new revision",
+			steps: []any{deploymentAt("1", 3, 3), deploymentAt("2", 3, 0), deploymentAt("2", 3, 3)},
+			want:  []string{rolloutStarted, rolloutCompleted},
+		},
+		{
+			name:  "scaling is not a rollout",
+			steps: []any{deploymentAt("1", 3, 3), deploymentAt("1", 5, 4), deploymentAt("1", 5, 5)},
+		},
+		{
+			name:  "daemonset updateStrategy edit",
+			steps: []any{daemonSetAt(1, "1", 3), daemonSetAt(2, "1", 3)},
+		},
+		{
+			name:  "daemonset template edit",
+			steps: []any{daemonSetAt(2, "1", 3), daemonSetAt(3, "2", 0), daemonSetAt(3, "2", 3)},
+			want:  []string{rolloutStarted, rolloutCompleted},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewRolloutFetcher(nopLogger{}, FetcherConfig{ClusterID: "test"}, 0, nil)
+			var got []string
+			for i := 1; i < len(tt.steps); i++ {
+				oldState, _ := rolloutStateOf(tt.steps[i-1])
+				newState, _ := rolloutStateOf(tt.steps[i])
+				for _, ev := range f.transitions(context.Background(), oldState, newState) {
+					got = append(got, ev.Attributes()["rollout.phase"])
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("phases = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaemonSetRevisionWithoutAnnotation(t *testing.T) {
+	ds := daemonSetAt(1, "", 3)
+	before := daemonSetRevision(ds)
+
+	ds.Generation = 2
+	ds.Spec.UpdateStrategy.Type = appsv1.OnDeleteDaemonSetStrategyType
+	if got := daemonSetRevision(ds); got != before {
+		t.Fatalf("revision changed on updateStrategy edit: %s -> %s", before, got)
+	}
+
+	ds.Spec.Template.Labels = map[string]string{"version": "2"}
+	if got := daemonSetRevision(ds); got == before {
+		t.Fatalf("revision did not change on template edit: %s", got)
+	}
+}
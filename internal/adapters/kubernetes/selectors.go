@@ -0,0 +1,79 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// EventFieldSelectors are evaluated by the API server, so filtered events are
+// never streamed into KENT. Field selectors only support equality, hence one
+// value per field.
+type EventFieldSelectors struct {
+	InvolvedObjectKind string
+	Type               string
+	Reason             string
+	Source             string
+}
+
+// fieldSelector builds the selector for one watch. Excluded namespaces are
+// pushed to the server as well when the watch spans the whole cluster.
+func (s EventFieldSelectors) fieldSelector(eventsV1 bool, namespace string, exclude []string) string {
+	var selectors []fields.Selector
+
+	kindField, sourceField := "involvedObject.kind", "source"
+	if eventsV1 {
+		kindField, sourceField = "regarding.kind", "reportingController"
+	}
+
+	if s.InvolvedObjectKind != "" {
+		selectors = append(selectors, fields.OneTermEqualSelector(kindField, s.InvolvedObjectKind))
+	}
+	if s.Type != "" {
+		selectors = append(selectors, fields.OneTermEqualSelector("type", s.Type))
+	}
+	if s.Reason != "" {
+		selectors = append(selectors, fields.OneTermEqualSelector("reason", s.Reason))
+	}
+	if s.Source != "" {
+		selectors = append(selectors, fields.OneTermEqualSelector(sourceField, s.Source))
+	}
+
+	if namespace == "" {
+		for _, ns := range exclude {
+			selectors = append(selectors, fields.OneTermNotEqualSelector("metadata.namespace", ns))
+		}
+	}
+
+	if len(selectors) == 0 {
+		return ""
+	}
+	return fields.AndSelectors(selectors...).String()
+}
+
+// watchNamespaces returns the namespaces to open a watch for; a single ""
+// stands for a cluster-wide watch.
+func watchNamespaces(include []string) []string {
+	if len(include) == 0 {
+		return []string{""}
+	}
+	return include
+}
+
+// checkpointKey names the position of one watch in the checkpoint store.
+// Keys are prefixed with the cluster ID, if any, so several clusters can
+// share one store.
+func checkpointKey(clusterID, namespace string) string {
+	key := namespace
+	if key == "" {
+		key = clusterScopeKey
+	}
+	if clusterID != "" {
+		key = clusterID + "." + key
+	}
+	return key
+}
@@ -0,0 +1,53 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package kubernetes
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEventFieldSelectors(t *testing.T) {
+	all := EventFieldSelectors{InvolvedObjectKind: "Pod", Type: "Warning", Reason: "BackOff", Source: "kubelet"}
+
+	tests := []struct {
+		name      string
+		selectors EventFieldSelectors
+		eventsV1  bool
+		namespace string
+		exclude   []string
+		want      string
+	}{
+		{"empty", EventFieldSelectors{}, false, "", nil, ""},
+		{"core/v1", all, false, "", nil, "involvedObject.kind=Pod,type=Warning,reason=BackOff,source=kubelet"},
+		{"events.k8s.io/v1", all, true, "", nil, "regarding.kind=Pod,type=Warning,reason=BackOff,reportingController=kubelet"},
+		{"single field", EventFieldSelectors{Type: "Warning"}, false, "", nil, "type=Warning"},
+		{"excluded namespaces", EventFieldSelectors{}, false, "", []string{"kube-system", "flux-system"},
+			"metadata.namespace!=kube-system,metadata.namespace!=flux-system"},
+		{"exclusions ignored in a namespaced watch", EventFieldSelectors{Reason: "Killing"}, false, "apps", []string{"kube-system"},
+			"reason=Killing"},
+		{"fields and exclusions", EventFieldSelectors{Type: "Warning"}, true, "", []string{"kube-system"},
+			"type=Warning,metadata.namespace!=kube-system"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.selectors.fieldSelector(tt.eventsV1, tt.namespace, tt.exclude); got != tt.want {
+				t.Fatalf("fieldSelector = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchNamespaces(t *testing.T) {
+	if got := watchNamespaces(nil); !slices.Equal(got, []string{""}) {
+		t.Fatalf("watchNamespaces(nil) = %q, want a cluster-wide watch", got)
+	}
+	if got := watchNamespaces([]string{"a", "b"}); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("watchNamespaces = %q, want [a b]", got)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package victorialogs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuthConfig holds the credentials sent with every request, e.g. to vmauth.
+// Basic auth and a bearer token are mutually exclusive; BearerTokenFile is
+// re-read when the file changes, so rotated tokens are picked up.
+type AuthConfig struct {
+	Username        string
+	Password        string
+	BearerToken     string
+	BearerTokenFile string
+	// Headers are added to every request.
+	Headers map[string]string
+}
+
+// TLSConfig configures the connection to an https endpoint. CAFile adds a
+// bundle to verify the server; CertFile and KeyFile enable mTLS.
+type TLSConfig struct {
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+func (c AuthConfig) validate() error {
+	bearer := c.BearerToken != "" || c.BearerTokenFile != ""
+	if c.BearerToken != "" && c.BearerTokenFile != "" {
+		return fmt.Errorf("adapters:victorialogs:writer: bearer token and bearer token file are mutually exclusive")
+	}
+	if c.Username != "" && bearer {
+		return fmt.Errorf("adapters:victorialogs:writer: basic auth and bearer token are mutually exclusive")
+	}
+	if c.Password != "" && c.Username == "" {
+		return fmt.Errorf("adapters:victorialogs:writer: password requires a username")
+	}
+	return nil
+}
+
+func newHTTPClient(timeout time.Duration, cfg TLSConfig) (*http.Client, error) {
+	if cfg == (TLSConfig{}) {
+		return &http.Client{Timeout: timeout}, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("adapters:victorialogs:writer: failed to read ca file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("adapters:victorialogs:writer: no certificates found in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, fmt.Errorf("adapters:victorialogs:writer: tls cert and key must be set together")
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("adapters:victorialogs:writer: failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// tokenFile caches a bearer token read from disk and re-reads it when the
+// file's modification time or size changes.
+type tokenFile struct {
+	path string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+}
+
+func (t *tokenFile) get() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	info, err := os.Stat(t.path)
+	if err != nil {
+		// a rotation may briefly remove the file; keep the last token
+		if t.token != "" {
+			return t.token, nil
+		}
+		return "", fmt.Errorf("failed to read bearer token file: %w", err)
+	}
+	if t.token != "" && info.ModTime().Equal(t.modTime) && info.Size() == t.size {
+		return t.token, nil
+	}
+
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		if t.token != "" {
+			return t.token, nil
+		}
+		return "", fmt.Errorf("failed to read bearer token file: %w", err)
+	}
+	t.token = strings.TrimSpace(string(data))
+	t.modTime = info.ModTime()
+	t.size = info.Size()
+	return t.token, nil
+}
+
+// redactedHeaders returns h with credentials masked, for debug logging.
+func redactedHeaders(h http.Header) http.Header {
+	if h.Get("Authorization") == "" {
+		return h
+	}
+	h = h.Clone()
+	h.Set("Authorization", "<redacted>")
+	return h
+}
+
+// authorize sets the configured headers and credentials on req.
+func (w *Writer) authorize(req *http.Request) error {
+	for k, v := range w.auth.Headers {
+		req.Header.Set(k, v)
+	}
+
+	switch {
+	case w.auth.Username != "":
+		req.SetBasicAuth(w.auth.Username, w.auth.Password)
+	case w.auth.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+w.auth.BearerToken)
+	case w.token != nil:
+		token, err := w.token.get()
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package victorialogs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTokenFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	mtime := time.Now().Add(-time.Hour)
+
+	write := func(t *testing.T, token string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(token), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		// distinct mtimes, so same-size rotations are seen without sleeping
+		mtime = mtime.Add(time.Second)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	remove := func(t *testing.T) {
+		t.Helper()
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	steps := []struct {
+		name    string
+		change  func(t *testing.T)
+		want    string
+		wantErr bool
+	}{
+		{"missing file before first read", func(*testing.T) {}, "", true},
+		{"initial token is trimmed", func(t *testing.T) { write(t, "first\n") }, "first", false},
+		{"unchanged file", func(*testing.T) {}, "first", false},
+		{"rotated to a longer token", func(t *testing.T) { write(t, "second-token\n") }, "second-token", false},
+		{"rotated to a token of the same size", func(t *testing.T) { write(t, "third--token\n") }, "third--token", false},
+		{"file briefly removed", remove, "third--token", false},
+		{"file back with a new token", func(t *testing.T) { write(t, "fourth") }, "fourth", false},
+	}
+
+	tf := &tokenFile{path: path}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			step.change(t)
+			got, err := tf.get()
+			if (err != nil) != step.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, step.wantErr)
+			}
+			if got != step.want {
+				t.Fatalf("token = %q, want %q", got, step.want)
+			}
+		})
+	}
+}
+
+func TestAuthConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     AuthConfig
+		wantErr bool
+	}{
+		{"none", AuthConfig{}, false},
+		{"basic", AuthConfig{Username: "kent", Password: "secret"}, false},
+		{"token", AuthConfig{BearerToken: "t"}, false},
+		{"token file", AuthConfig{BearerTokenFile: "/var/run/token"}, false},
+		{"token and file", AuthConfig{BearerToken: "t", BearerTokenFile: "/var/run/token"}, true},
+		{"basic and token", AuthConfig{Username: "kent", BearerToken: "t"}, true},
+		{"password without user", AuthConfig{Password: "secret"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package victorialogs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressor encodes request bodies with the configured Content-Encoding.
+// A nil compressor sends bodies as is.
+type compressor struct {
+	encoding string
+	level    int
+	zstd     *zstd.Encoder
+}
+
+// newCompressor accepts "", "none", "gzip" and "zstd". level 0 selects the
+// default of the algorithm; gzip takes 1-9, zstd the usual 1-22 scale.
+func newCompressor(name string, level int) (*compressor, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return nil, nil
+	case "gzip":
+		if level < 0 || level > gzip.BestCompression {
+			return nil, fmt.Errorf("adapters:victorialogs:writer: invalid gzip level %d; expected 1-9", level)
+		}
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return &compressor{encoding: "gzip", level: level}, nil
+	case "zstd":
+		if level < 0 || level > 22 {
+			return nil, fmt.Errorf("adapters:victorialogs:writer: invalid zstd level %d; expected 1-22", level)
+		}
+		opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+		if level != 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		enc, err := zstd.NewWriter(nil, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("adapters:victorialogs:writer: failed to init zstd: %w", err)
+		}
+		return &compressor{encoding: "zstd", level: level, zstd: enc}, nil
+	default:
+		return nil, fmt.Errorf("adapters:victorialogs:writer: unknown compression %q; expected none, gzip or zstd", name)
+	}
+}
+
+func (c *compressor) compress(body []byte) ([]byte, error) {
+	if c.zstd != nil {
+		return c.zstd.EncodeAll(body, make([]byte, 0, len(body)/4)), nil
+	}
+
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, c.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
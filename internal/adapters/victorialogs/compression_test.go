@@ -0,0 +1,106 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package victorialogs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNewCompressor(t *testing.T) {
+	tests := []struct {
+		name     string
+		algo     string
+		level    int
+		encoding string
+		wantErr  bool
+	}{
+		{"none", "none", 0, "", false},
+		{"empty", "", 0, "", false},
+		{"gzip default", "gzip", 0, "gzip", false},
+		{"gzip fastest", "gzip", 1, "gzip", false},
+		{"gzip best", "gzip", 9, "gzip", false},
+		{"gzip uppercase", "GZIP", 0, "gzip", false},
+		{"gzip too high", "gzip", 10, "", true},
+		{"gzip default constant", "gzip", -1, "", true},
+		{"gzip huffman only", "gzip", -2, "", true},
+		{"zstd default", "zstd", 0, "zstd", false},
+		{"zstd fastest", "zstd", 1, "zstd", false},
+		{"zstd best", "zstd", 22, "zstd", false},
+		{"zstd too high", "zstd", 23, "", true},
+		{"zstd negative", "zstd", -1, "", true},
+		{"unknown", "brotli", 0, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := newCompressor(tt.algo, tt.level)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if tt.encoding == "" {
+				if c != nil {
+					t.Fatalf("compressor = %+v, want nil", c)
+				}
+				return
+			}
+			if c.encoding != tt.encoding {
+				t.Fatalf("encoding = %q, want %q", c.encoding, tt.encoding)
+			}
+		})
+	}
+}
+
+func TestCompressorRoundTrip(t *testing.T) {
+	body := bytes.Repeat([]byte(`{"message":"pod started"}`+"\n"), 100)
+
+	for _, algo := range []string{"gzip", "zstd"} {
+		t.Run(algo, func(t *testing.T) {
+			c, err := newCompressor(algo, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := c.compress(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []byte
+			switch algo {
+			case "gzip":
+				zr, err := gzip.NewReader(bytes.NewReader(out))
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err = io.ReadAll(zr)
+				if err != nil {
+					t.Fatal(err)
+				}
+			case "zstd":
+				zr, err := zstd.NewReader(nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer zr.Close()
+				got, err = zr.DecodeAll(out, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !bytes.Equal(got, body) {
+				t.Fatalf("round trip changed the body")
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package victorialogs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const segmentExt = ".seg"
+
+// QueueConfig enables the on-disk queue between batching and sending. Each
+// batch is stored as one segment file holding the encoded request body.
+// When the queue grows beyond MaxSize bytes or a segment gets older than
+// MaxAge, the oldest segments are discarded.
+type QueueConfig struct {
+	Path    string
+	MaxSize int64
+	MaxAge  time.Duration
+}
+
+type segment struct {
+	path    string
+	size    int64
+	entries int
+	created time.Time
+}
+
+// segmentQueue is a FIFO of segment files. Segments are named by an
+// increasing sequence number, so a restarted writer replays them in order.
+type segmentQueue struct {
+	dir     string
+	maxSize int64
+	maxAge  time.Duration
+
+	mu       sync.Mutex
+	segments []segment
+	size     int64
+	seq      uint64
+	notify   chan struct{}
+	// busy is the segment being sent; eviction leaves it alone.
+	busy string
+}
+
+func openQueue(cfg QueueConfig) (*segmentQueue, error) {
+	if err := os.MkdirAll(cfg.Path, 0o755); err != nil {
+		return nil, fmt.Errorf("adapters:victorialogs:queue: cannot create directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("adapters:victorialogs:queue: cannot read directory: %w", err)
+	}
+
+	q := &segmentQueue{
+		dir:     cfg.Path,
+		maxSize: cfg.MaxSize,
+		maxAge:  cfg.MaxAge,
+		notify:  make(chan struct{}, 1),
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasSuffix(name, segmentExt+".tmp") {
+			// a segment that was being written when the process died
+			_ = os.Remove(filepath.Join(cfg.Path, name))
+			continue
+		}
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(cfg.Path, name)
+		body, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		q.segments = append(q.segments, segment{
+			path:    path,
+			size:    info.Size(),
+			entries: countLines(body),
+			created: info.ModTime(),
+		})
+		q.size += info.Size()
+		q.seq = max(q.seq, seq)
+	}
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i].path < q.segments[j].path })
+
+	if len(q.segments) > 0 {
+		q.signal()
+	}
+	return q, nil
+}
+
+// push stores body as a new segment and returns the segments evicted to stay
+// within the size bound.
+func (q *segmentQueue) push(body []byte) ([]segment, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.seq++
+	path := filepath.Join(q.dir, fmt.Sprintf("%020d%s", q.seq, segmentExt))
+	tmp := path + ".tmp"
+
+	if err := writeSegment(tmp, body); err != nil {
+		_ = os.Remove(tmp)
+		return nil, fmt.Errorf("adapters:victorialogs:queue: cannot write segment: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return nil, fmt.Errorf("adapters:victorialogs:queue: cannot commit segment: %w", err)
+	}
+
+	q.segments = append(q.segments, segment{
+		path:    path,
+		size:    int64(len(body)),
+		entries: countLines(body),
+		created: time.Now(),
+	})
+	q.size += int64(len(body))
+
+	// the newest segment is always kept
+	var evicted []segment
+	for q.maxSize > 0 && q.size > q.maxSize {
+		i := 0
+		if q.segments[0].path == q.busy {
+			i = 1
+		}
+		if i >= len(q.segments)-1 {
+			break
+		}
+		evicted = append(evicted, q.removeLocked(i))
+	}
+
+	q.signal()
+	return evicted, nil
+}
+
+// expire discards segments older than the age bound and returns them.
+func (q *segmentQueue) expire() []segment {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var evicted []segment
+	for q.maxAge > 0 && len(q.segments) > 0 && time.Since(q.segments[0].created) > q.maxAge {
+		evicted = append(evicted, q.removeLocked(0))
+	}
+	return evicted
+}
+
+// next returns the segment to send next and marks it busy until it is
+// removed or released.
+func (q *segmentQueue) next() (segment, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.segments) == 0 {
+		return segment{}, false
+	}
+	q.busy = q.segments[0].path
+	return q.segments[0], true
+}
+
+// release clears the busy mark of a segment that stays queued.
+func (q *segmentQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.busy = ""
+}
+
+// remove deletes a segment once it has been sent.
+func (q *segmentQueue) remove(seg segment) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.busy == seg.path {
+		q.busy = ""
+	}
+	for i, s := range q.segments {
+		if s.path == seg.path {
+			q.removeLocked(i)
+			return
+		}
+	}
+}
+
+func (q *segmentQueue) removeLocked(i int) segment {
+	seg := q.segments[i]
+	q.segments = append(q.segments[:i], q.segments[i+1:]...)
+	q.size -= seg.size
+	_ = os.Remove(seg.path)
+	return seg
+}
+
+// writeSegment writes and syncs body, so a renamed segment is complete
+// even after a node crash.
+func writeSegment(path string, body []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(body); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (q *segmentQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package victorialogs
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// bodies returns the contents of the queued segments, oldest first.
+func bodies(t *testing.T, q *segmentQueue) []string {
+	t.Helper()
+	var out []string
+	for _, seg := range q.segments {
+		body, err := os.ReadFile(seg.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, string(body))
+	}
+	return out
+}
+
+func TestSegmentQueueReplaysInOrder(t *testing.T) {
+	dir := t.TempDir()
+
+	q, err := openQueue(QueueConfig{Path: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []string{"a\n", "b\n", "c\n"} {
+		if _, err := q.push([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// left behind by a crash while writing
+	if err := os.WriteFile(filepath.Join(dir, "00000000000000000099.seg.tmp"), []byte("x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := openQueue(QueueConfig{Path: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := bodies(t, reopened), []string{"a\n", "b\n", "c\n"}; !slices.Equal(got, want) {
+		t.Fatalf("replayed %q, want %q", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "00000000000000000099.seg.tmp")); !os.IsNotExist(err) {
+		t.Fatalf("partial segment was not removed: %v", err)
+	}
+
+	// new segments continue the sequence
+	if _, err := reopened.push([]byte("d\n")); err != nil {
+		t.Fatal(err)
+	}
+	seg, _ := reopened.next()
+	reopened.remove(seg)
+	if got, want := bodies(t, reopened), []string{"b\n", "c\n", "d\n"}; !slices.Equal(got, want) {
+		t.Fatalf("queue = %q, want %q", got, want)
+	}
+}
+
+func TestSegmentQueueMaxSize(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxSize     int64
+		busy        bool
+		want        []string
+		wantEvicted int
+	}{
+		{"unbounded", 0, false, []string{"a\n", "b\n", "c\n", "d\n"}, 0},
+		{"oldest evicted", 4, false, []string{"c\n", "d\n"}, 2},
+		{"newest is kept", 1, false, []string{"d\n"}, 3},
+		{"segment in flight is kept", 4, true, []string{"a\n", "d\n"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := openQueue(QueueConfig{Path: t.TempDir(), MaxSize: tt.maxSize})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			evicted := 0
+			for i, body := range []string{"a\n", "b\n", "c\n", "d\n"} {
+				segs, err := q.push([]byte(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				evicted += len(segs)
+				if i == 0 && tt.busy {
+					q.next()
+				}
+			}
+
+			if got := bodies(t, q); !slices.Equal(got, tt.want) {
+				t.Fatalf("queue = %q, want %q", got, tt.want)
+			}
+			if evicted != tt.wantEvicted {
+				t.Fatalf("evicted %d segments, want %d", evicted, tt.wantEvicted)
+			}
+		})
+	}
+}
+
+func TestSegmentQueueMaxAge(t *testing.T) {
+	q, err := openQueue(QueueConfig{Path: t.TempDir(), MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []string{"a\n", "b\n", "c\n"} {
+		if _, err := q.push([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.segments[0].created = time.Now().Add(-2 * time.Hour)
+	q.segments[1].created = time.Now().Add(-2 * time.Hour)
+
+	expired := q.expire()
+	if len(expired) != 2 {
+		t.Fatalf("expired %d segments, want 2", len(expired))
+	}
+	for _, seg := range expired {
+		if _, err := os.Stat(seg.path); !os.IsNotExist(err) {
+			t.Fatalf("expired segment %s still on disk", seg.path)
+		}
+	}
+	if got, want := bodies(t, q), []string{"c\n"}; !slices.Equal(got, want) {
+		t.Fatalf("queue = %q, want %q", got, want)
+	}
+}
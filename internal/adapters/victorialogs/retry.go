@@ -0,0 +1,172 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package victorialogs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// RetryConfig bounds how long a failing batch is retried. Backoff grows
+// exponentially from InitialInterval to MaxInterval with jitter; after
+// MaxElapsedTime the batch is dropped. A zero MaxElapsedTime disables
+// retries.
+type RetryConfig struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	MaxElapsedTime  time.Duration
+}
+
+// sendError is a failed request. Retriable errors are connection failures,
+// 408, 429 and 5xx responses; other 4xx responses are permanent.
+type sendError struct {
+	err        error
+	status     int
+	retryAfter time.Duration
+}
+
+func (e *sendError) Error() string { return e.err.Error() }
+func (e *sendError) Unwrap() error { return e.err }
+
+func (e *sendError) retriable() bool {
+	switch {
+	case e.status == 0:
+		return true
+	case e.status == http.StatusRequestTimeout, e.status == http.StatusTooManyRequests:
+		return true
+	case e.status >= 500:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter understands the delay-seconds form of Retry-After.
+func parseRetryAfter(v string) time.Duration {
+	secs, err := strconv.Atoi(v)
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// Stats are the writer's delivery counters since start.
+type Stats struct {
+	BatchesSent    uint64
+	Retries        uint64
+	BatchesDropped uint64
+	EntriesDropped uint64
+}
+
+type stats struct {
+	batchesSent    atomic.Uint64
+	retries        atomic.Uint64
+	batchesDropped atomic.Uint64
+	entriesDropped atomic.Uint64
+}
+
+// Stats returns a snapshot of the delivery counters.
+func (w *Writer) Stats() Stats {
+	return Stats{
+		BatchesSent:    w.stats.batchesSent.Load(),
+		Retries:        w.stats.retries.Load(),
+		BatchesDropped: w.stats.batchesDropped.Load(),
+		EntriesDropped: w.stats.entriesDropped.Load(),
+	}
+}
+
+// Counters exposes the delivery counters on the health server's /metrics.
+func (w *Writer) Counters() map[string]uint64 {
+	s := w.Stats()
+	return map[string]uint64{
+		"kent_victorialogs_batches_sent_total":    s.BatchesSent,
+		"kent_victorialogs_retries_total":         s.Retries,
+		"kent_victorialogs_batches_dropped_total": s.BatchesDropped,
+		"kent_victorialogs_entries_dropped_total": s.EntriesDropped,
+	}
+}
+
+// postWithRetry posts body, retrying retriable failures until the retry
+// budget is spent or ctx is done, and returns the last error.
+func (w *Writer) postWithRetry(ctx context.Context, body []byte) error {
+	start := time.Now()
+	interval := w.retry.InitialInterval
+
+	for attempt := 1; ; attempt++ {
+		err := w.post(ctx, body)
+		if err == nil {
+			w.stats.batchesSent.Add(1)
+			return nil
+		}
+
+		var se *sendError
+		retriable := errors.As(err, &se) && se.retriable()
+
+		wait := jitter(interval)
+		if se != nil && se.retryAfter > wait {
+			wait = se.retryAfter
+		}
+
+		if !retriable || ctx.Err() != nil || time.Since(start)+wait > w.retry.MaxElapsedTime {
+			return fmt.Errorf("attempt %d: %w", attempt, err)
+		}
+
+		w.stats.retries.Add(1)
+		w.logger.Warn(ctx, "adapters:victorialogs:writer: failed to send batch, retrying",
+			"attempt", attempt,
+			"retry_in", wait.String(),
+			"error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		interval = min(interval*2, w.retry.MaxInterval)
+	}
+}
+
+// permanent reports whether resending the same body cannot succeed.
+func permanent(err error) bool {
+	var se *sendError
+	return !errors.As(err, &se) || !se.retriable()
+}
+
+func (w *Writer) drop(ctx context.Context, count int, err error) {
+	w.stats.batchesDropped.Add(1)
+	w.stats.entriesDropped.Add(uint64(count))
+	w.logger.Error(ctx, "adapters:victorialogs:writer: dropping batch",
+		"count", count,
+		"error", err,
+		"batches_dropped_total", w.stats.batchesDropped.Load(),
+		"entries_dropped_total", w.stats.entriesDropped.Load(),
+	)
+}
+
+// jitter spreads retries of several replicas over [d/2, d).
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + rand.N(half)
+}
+
+func newSendError(status int, retryAfter string, format string, args ...any) *sendError {
+	return &sendError{
+		err:        fmt.Errorf(format, args...),
+		status:     status,
+		retryAfter: parseRetryAfter(retryAfter),
+	}
+}
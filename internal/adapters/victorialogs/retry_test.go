@@ -0,0 +1,86 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package victorialogs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSendErrorRetriable(t *testing.T) {
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{0, true},
+		{http.StatusRequestTimeout, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusBadRequest, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusRequestEntityTooLarge, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.status), func(t *testing.T) {
+			err := &sendError{err: errors.New("failed"), status: tt.status}
+			if got := err.retriable(); got != tt.want {
+				t.Fatalf("retriable = %v, want %v", got, tt.want)
+			}
+			if got := permanent(fmt.Errorf("attempt 1: %w", err)); got == tt.want {
+				t.Fatalf("permanent = %v, want %v", got, !tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"-5", 0},
+		{"7", 7 * time.Second},
+		{"120", 2 * time.Minute},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := parseRetryAfter(tt.in); got != tt.want {
+				t.Fatalf("parseRetryAfter(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJitter(t *testing.T) {
+	tests := []time.Duration{0, 1, 2, time.Millisecond, time.Second, 30 * time.Second}
+
+	for _, d := range tests {
+		t.Run(d.String(), func(t *testing.T) {
+			for range 100 {
+				got := jitter(d)
+				if d <= 1 {
+					if got != d {
+						t.Fatalf("jitter(%v) = %v, want %v", d, got, d)
+					}
+					continue
+				}
+				if got < d/2 || got >= d {
+					t.Fatalf("jitter(%v) = %v, want within [%v, %v)", d, got, d/2, d)
+				}
+			}
+		})
+	}
+}
@@ -10,14 +10,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"event_exporter/internal/domain"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
+// ErrStopped is returned by Write after Stop was called.
+var ErrStopped = errors.New("adapters:victorialogs:writer: writer stopped")
+
 type Logger interface {
 	Debug(ctx context.Context, msg string, kv ...any)
 	Info(ctx context.Context, msg string, kv ...any)
@@ -36,21 +43,50 @@ type VictoriaLogsConfig struct {
 	AccountID    string
 	ProjectID    string
 	StreamFields []string
+	Retry        RetryConfig
+	// Queue is optional; without a path batches are sent from memory.
+	Queue QueueConfig
+	// Compression is the Content-Encoding of requests: none, gzip or zstd.
+	Compression      string
+	CompressionLevel int
+	// MaxBatchBytes flushes a batch before its uncompressed body exceeds
+	// this size; 0 disables the limit.
+	MaxBatchBytes int
+	Auth          AuthConfig
+	TLS           TLSConfig
 }
 
 type Writer struct {
-	client       *http.Client
-	logger       Logger
-	endpoint     string
-	clusterID    string
-	batchSize    int
-	flushTime    time.Duration
-	extra        map[string]string
-	input        chan *domain.LogEntry
-	cancelFunc   context.CancelFunc
-	accountID    string
-	projectID    string
-	streamFields []string
+	client        *http.Client
+	logger        Logger
+	endpoint      string
+	clusterID     string
+	batchSize     int
+	flushTime     time.Duration
+	extra         map[string]string
+	input         chan *domain.LogEntry
+	cancelFunc    context.CancelFunc
+	stop          chan struct{}
+	stopOnce      sync.Once
+	flushed       chan struct{}
+	done          chan struct{}
+	accountID     string
+	projectID     string
+	streamFields  []string
+	retry         RetryConfig
+	queue         *segmentQueue
+	compressor    *compressor
+	maxBatchBytes int
+	auth          AuthConfig
+	token         *tokenFile
+	stats         stats
+
+	// stopping is closed first by Stop and makes blocked Writes return;
+	// stop is closed once no Write is in flight, so run drains input only
+	// after the last send. stopMu orders inflight.Add against Stop.
+	stopping chan struct{}
+	stopMu   sync.RWMutex
+	inflight sync.WaitGroup
 }
 
 func NewWriter(cfg VictoriaLogsConfig, logger Logger) (*Writer, error) {
@@ -81,24 +117,79 @@ func NewWriter(cfg VictoriaLogsConfig, logger Logger) (*Writer, error) {
 	if cfg.Timeout <= 0 {
 		cfg.Timeout = 10 * time.Second
 	}
+	if cfg.Retry.InitialInterval <= 0 {
+		cfg.Retry.InitialInterval = time.Second
+	}
+	if cfg.Retry.MaxInterval < cfg.Retry.InitialInterval {
+		cfg.Retry.MaxInterval = max(30*time.Second, cfg.Retry.InitialInterval)
+	}
+
+	comp, err := newCompressor(cfg.Compression, cfg.CompressionLevel)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Auth.validate(); err != nil {
+		return nil, err
+	}
+	client, err := newHTTPClient(cfg.Timeout, cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
 
 	w := &Writer{
-		client:       &http.Client{Timeout: cfg.Timeout},
-		logger:       logger,
-		endpoint:     cfg.Endpoint,
-		clusterID:    cfg.ClusterID,
-		batchSize:    cfg.BatchSize,
-		flushTime:    cfg.FlushTime,
-		extra:        cfg.ExtraFields,
-		input:        make(chan *domain.LogEntry, 5000),
-		accountID:    cfg.AccountID,
-		projectID:    cfg.ProjectID,
-		streamFields: cfg.StreamFields,
+		client:        client,
+		logger:        logger,
+		endpoint:      cfg.Endpoint,
+		clusterID:     cfg.ClusterID,
+		batchSize:     cfg.BatchSize,
+		flushTime:     cfg.FlushTime,
+		extra:         cfg.ExtraFields,
+		input:         make(chan *domain.LogEntry, 5000),
+		stop:          make(chan struct{}),
+		stopping:      make(chan struct{}),
+		flushed:       make(chan struct{}),
+		done:          make(chan struct{}),
+		accountID:     cfg.AccountID,
+		projectID:     cfg.ProjectID,
+		streamFields:  cfg.StreamFields,
+		retry:         cfg.Retry,
+		compressor:    comp,
+		maxBatchBytes: cfg.MaxBatchBytes,
+		auth:          cfg.Auth,
+	}
+	if cfg.Auth.BearerTokenFile != "" {
+		w.token = &tokenFile{path: cfg.Auth.BearerTokenFile}
+		if _, err := w.token.get(); err != nil {
+			return nil, fmt.Errorf("adapters:victorialogs:writer: %w", err)
+		}
+	}
+
+	if cfg.Queue.Path != "" {
+		q, err := openQueue(cfg.Queue)
+		if err != nil {
+			return nil, err
+		}
+		w.queue = q
+		if n := len(q.segments); n > 0 {
+			logger.Info(context.Background(), "adapters:victorialogs:writer: replaying queued batches", "segments", n, "bytes", q.size)
+		}
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	w.cancelFunc = cancel
-	go w.run(ctx)
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		defer close(w.flushed)
+		w.run(ctx)
+	})
+	if w.queue != nil {
+		wg.Go(func() { w.drain(ctx) })
+	}
+	go func() {
+		wg.Wait()
+		close(w.done)
+	}()
 
 	logger.Info(
 		context.Background(),
@@ -106,14 +197,34 @@ func NewWriter(cfg VictoriaLogsConfig, logger Logger) (*Writer, error) {
 		"endpoint", cfg.Endpoint,
 		"batch_size", cfg.BatchSize,
 		"flush_time", cfg.FlushTime.String(),
+		"retry_max_elapsed_time", cfg.Retry.MaxElapsedTime.String(),
+		"queue", cfg.Queue.Path,
+		"compression", cfg.Compression,
+		"max_batch_bytes", cfg.MaxBatchBytes,
 	)
+	if cfg.TLS.InsecureSkipVerify {
+		logger.Warn(context.Background(), "adapters:victorialogs:writer: tls certificate verification is disabled")
+	}
 	return w, nil
 }
 
 func (w *Writer) Write(ctx context.Context, logs []*domain.LogEntry) error {
+	w.stopMu.RLock()
+	select {
+	case <-w.stopping:
+		w.stopMu.RUnlock()
+		return ErrStopped
+	default:
+	}
+	w.inflight.Add(1)
+	w.stopMu.RUnlock()
+	defer w.inflight.Done()
+
 	for _, l := range logs {
 		select {
 		case w.input <- l:
+		case <-w.stopping:
+			return ErrStopped
 		case <-ctx.Done():
 			return ctx.Err()
 		}
@@ -125,29 +236,58 @@ func (w *Writer) run(ctx context.Context) {
 	ticker := time.NewTicker(w.flushTime)
 	defer ticker.Stop()
 
-	var buffer []*domain.LogEntry
+	var (
+		buffer []*domain.LogEntry
+		body   bytes.Buffer
+	)
 
 	flush := func() {
 		if len(buffer) == 0 {
 			return
 		}
-		if err := w.sendBatch(ctx, buffer); err != nil {
-			w.logger.Error(ctx, "adapters:victorialogs:writer: failed to send batch", "error", err)
-		}
+		w.flush(ctx, buffer, body.Bytes())
 		buffer = nil
+		body.Reset()
+	}
+
+	// add appends an entry and reports whether the batch was flushed because
+	// it reached the entry or byte limit.
+	add := func(entry *domain.LogEntry) bool {
+		line, err := w.encode(entry)
+		if err != nil {
+			w.drop(ctx, 1, err)
+			entry.Delivered()
+			return false
+		}
+
+		flushed := false
+		if w.maxBatchBytes > 0 && len(buffer) > 0 && body.Len()+len(line) > w.maxBatchBytes {
+			flush()
+			flushed = true
+		}
+		buffer = append(buffer, entry)
+		body.Write(line)
+		if len(buffer) >= w.batchSize || (w.maxBatchBytes > 0 && body.Len() >= w.maxBatchBytes) {
+			flush()
+			flushed = true
+		}
+		return flushed
 	}
 
 	for {
 		select {
-		case <-ctx.Done():
+		case <-w.stop:
+			// take what Write queued before Stop and send it with the
+			// remaining shutdown budget
+			for len(w.input) > 0 {
+				add(<-w.input)
+			}
 			flush()
 			return
 
 		case logEntry := <-w.input:
-			buffer = append(buffer, logEntry)
-			if len(buffer) >= w.batchSize {
+			if add(logEntry) {
 				ticker.Reset(w.flushTime)
-				flush()
 			}
 		case <-ticker.C:
 			ticker.Reset(w.flushTime)
@@ -156,74 +296,216 @@ func (w *Writer) run(ctx context.Context) {
 	}
 }
 
-func (w *Writer) sendBatch(ctx context.Context, batch []*domain.LogEntry) error {
-	var buf bytes.Buffer
-	enc := json.NewEncoder(&buf)
-	streamFields := append([]string{"clusterID"}, w.streamFields...)
+// flush hands one batch to the queue, or sends it right away when there is
+// no queue. Entries count as delivered once VictoriaLogs or the queue has
+// them.
+func (w *Writer) flush(ctx context.Context, batch []*domain.LogEntry, body []byte) {
+	if w.queue != nil {
+		evicted, err := w.queue.push(body)
+		if err == nil {
+			w.dropSegments(ctx, evicted, "queue size limit reached")
+			for _, entry := range batch {
+				entry.Delivered()
+			}
+			return
+		}
+		w.logger.Error(ctx, "adapters:victorialogs:writer: failed to queue batch, sending directly", "error", err)
+	}
 
+	if err := w.postWithRetry(ctx, body); err != nil {
+		w.drop(ctx, len(batch), err)
+		// a batch given up on is lost either way; releasing it lets the
+		// checkpoint move on. Batches aborted by shutdown are replayed.
+		if ctx.Err() == nil {
+			for _, entry := range batch {
+				entry.Delivered()
+			}
+		}
+		return
+	}
 	for _, entry := range batch {
-		doc := map[string]any{
-			"@timestamp": entry.Timestamp().UTC().Format(time.RFC3339),
-			"message":    entry.Message(),
-			"level":      entry.Level(),
-			"logType":    entry.LogType(),
-			"clusterID":  w.clusterID,
+		entry.Delivered()
+	}
+	w.logger.Info(ctx, "adapters:victorialogs: batch sent", "count", len(batch))
+}
+
+// drain sends queued segments oldest first. A segment whose retry budget
+// is spent stays at the head of the queue and is tried again later; only
+// permanent failures and the queue bounds discard data. After Stop it keeps
+// sending until the queue is empty or the Stop deadline cancels ctx.
+func (w *Writer) drain(ctx context.Context) {
+	for {
+		w.dropSegments(ctx, w.queue.expire(), "queue max age reached")
+
+		// checked before the queue: once run has queued its last batch, an
+		// empty queue means everything was sent
+		final := false
+		select {
+		case <-w.flushed:
+			final = true
+		default:
 		}
 
-		for k, v := range entry.Fields() {
-			doc[k] = v
+		seg, ok := w.queue.next()
+		if !ok {
+			if final {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.flushed:
+			case <-w.queue.notify:
+			}
+			continue
 		}
-		for k, v := range w.extra {
-			doc[k] = v
+
+		body, err := os.ReadFile(seg.path)
+		if err != nil {
+			w.logger.Error(ctx, "adapters:victorialogs:writer: dropping unreadable segment", "segment", seg.path, "error", err)
+			w.queue.remove(seg)
+			continue
 		}
 
-		if err := enc.Encode(doc); err != nil {
-			return fmt.Errorf("failed to encode log entry: %w", err)
+		err = w.postWithRetry(ctx, body)
+		switch {
+		case ctx.Err() != nil:
+			// shutting down, the segment is replayed on the next start
+			return
+		case err == nil:
+			w.queue.remove(seg)
+			w.logger.Info(ctx, "adapters:victorialogs: batch sent", "count", seg.entries, "segment", filepath.Base(seg.path))
+		case permanent(err):
+			w.queue.remove(seg)
+			w.drop(ctx, seg.entries, err)
+		default:
+			w.logger.Warn(ctx, "adapters:victorialogs:writer: endpoint unavailable, keeping segment queued",
+				"segment", filepath.Base(seg.path),
+				"retry_in", w.retry.MaxInterval.String(),
+				"error", err,
+			)
+			w.queue.release()
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.retry.MaxInterval):
+			}
 		}
 	}
+}
+
+func (w *Writer) dropSegments(ctx context.Context, segments []segment, reason string) {
+	for _, seg := range segments {
+		w.drop(ctx, seg.entries, fmt.Errorf("%s: discarded segment %s", reason, filepath.Base(seg.path)))
+	}
+}
+
+func countLines(body []byte) int {
+	return bytes.Count(body, []byte("\n"))
+}
+
+// encode renders one entry as a JSON line of the request body.
+func (w *Writer) encode(entry *domain.LogEntry) ([]byte, error) {
+	doc := map[string]any{
+		"@timestamp": entry.Timestamp().UTC().Format(time.RFC3339),
+		"message":    entry.Message(),
+		"level":      entry.Level(),
+		"logType":    entry.LogType(),
+		"clusterID":  w.clusterID,
+	}
+
+	for k, v := range entry.Fields() {
+		doc[k] = v
+	}
+	for k, v := range w.extra {
+		doc[k] = v
+	}
+
+	line, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode log entry: %w", err)
+	}
+	return append(line, '\n'), nil
+}
 
+func (w *Writer) post(ctx context.Context, body []byte) error {
+	streamFields := append([]string{"clusterID"}, w.streamFields...)
 	url := fmt.Sprintf("%s/insert/jsonline?_msg_field=message&_time_field=@timestamp&_stream_fields=%s", w.endpoint, strings.Join(streamFields, ","))
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &buf)
+	payload := body
+	if w.compressor != nil {
+		var err error
+		if payload, err = w.compressor.compress(body); err != nil {
+			return fmt.Errorf("failed to compress request body: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
+	if err := w.authorize(req); err != nil {
+		// the token file may be mid-rotation, so this is worth a retry
+		return newSendError(0, "", "%w", err)
+	}
 
 	req.Header.Set("Content-Type", "application/stream+json")
+	if w.compressor != nil {
+		req.Header.Set("Content-Encoding", w.compressor.encoding)
+	}
 	req.Header.Set("AccountID", w.accountID)
 	req.Header.Set("ProjectID", w.projectID)
 
 	w.logger.Debug(ctx,
 		"adapters:victorialogs: sending request",
 		"url", url,
-		"headers", req.Header,
-		"payload_preview", buf.String(),
+		"headers", redactedHeaders(req.Header),
+		"payload_bytes", len(payload),
+		"payload_preview", string(body),
 	)
 
 	resp, err := w.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send logs: %w", err)
+		return newSendError(0, "", "failed to send logs: %w", err)
 	}
 	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
+	respBody, _ := io.ReadAll(resp.Body)
 
 	w.logger.Debug(ctx,
 		"adapters:victorialogs: received response",
 		"status", resp.Status,
-		"body", string(body),
+		"body", string(respBody),
 	)
 
 	if resp.StatusCode >= 300 {
-		return fmt.Errorf("victorialogs returned non-2xx status: %s, body: %s", resp.Status, string(body))
+		return newSendError(resp.StatusCode, resp.Header.Get("Retry-After"), "victorialogs returned non-2xx status: %s, body: %s", resp.Status, string(respBody))
 	}
-
-	w.logger.Info(ctx, "adapters:victorialogs: batch sent", "count", len(batch))
 	return nil
 }
 
-func (w *Writer) Stop() {
-	if w.cancelFunc != nil {
+// Stop flushes the buffered entries and waits until they are sent (or
+// queued) or ctx expires, in which case the pending requests are aborted.
+// Queued segments that were not sent stay on disk for the next start.
+func (w *Writer) Stop(ctx context.Context) error {
+	w.stopOnce.Do(func() {
+		w.stopMu.Lock()
+		close(w.stopping)
+		w.stopMu.Unlock()
+
+		go func() {
+			w.inflight.Wait()
+			close(w.stop)
+		}()
+	})
+
+	select {
+	case <-w.done:
+		w.cancelFunc()
+		return nil
+	case <-ctx.Done():
 		w.cancelFunc()
+		<-w.done
+		return fmt.Errorf("adapters:victorialogs:writer: shutdown deadline exceeded: %w", ctx.Err())
 	}
 }
@@ -0,0 +1,180 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package victorialogs
+
+import (
+	"bytes"
+	"context"
+	"event_exporter/internal/domain"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(context.Context, string, ...any) {}
+func (nopLogger) Info(context.Context, string, ...any)  {}
+func (nopLogger) Warn(context.Context, string, ...any)  {}
+func (nopLogger) Error(context.Context, string, ...any) {}
+
+func TestWriterStopSendsQueuedBatch(t *testing.T) {
+	var lines atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lines.Add(int64(bytes.Count(body, []byte("\n"))))
+	}))
+	defer srv.Close()
+
+	w, err := NewWriter(VictoriaLogsConfig{
+		Enabled:   true,
+		Endpoint:  srv.URL,
+		BatchSize: 100,
+		FlushTime: time.Hour,
+		Queue:     QueueConfig{Path: t.TempDir()},
+	}, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []*domain.LogEntry
+	for range 3 {
+		entry, err := domain.NewLogEntry(time.Now(), "info", "event", "msg", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := w.Write(context.Background(), entries); err != nil {
+		t.Fatal(err)
+	}
+
+	// let drain wait on the empty queue, so the batch is queued only after
+	// Stop was called
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := w.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := lines.Load(); got != 3 {
+		t.Fatalf("sent %d entries, want 3", got)
+	}
+	if n := len(w.queue.segments); n != 0 {
+		t.Fatalf("%d segments left in the queue", n)
+	}
+}
+
+func TestWriterStopKeepsAcceptedEntries(t *testing.T) {
+	var lines atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lines.Add(int64(bytes.Count(body, []byte("\n"))))
+	}))
+	defer srv.Close()
+
+	w, err := NewWriter(VictoriaLogsConfig{
+		Enabled:   true,
+		Endpoint:  srv.URL,
+		BatchSize: 50,
+		FlushTime: time.Hour,
+	}, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var accepted atomic.Int64
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for {
+				entry, err := domain.NewLogEntry(time.Now(), "info", "event", "msg", nil)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if err := w.Write(context.Background(), []*domain.LogEntry{entry}); err != nil {
+					return
+				}
+				accepted.Add(1)
+			}
+		})
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := w.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	if got, want := lines.Load(), accepted.Load(); got != want {
+		t.Fatalf("sent %d entries, Write accepted %d", got, want)
+	}
+}
+
+func TestWriterStopHonorsDeadlineWithFullInput(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	w, err := NewWriter(VictoriaLogsConfig{
+		Enabled:   true,
+		Endpoint:  srv.URL,
+		BatchSize: 1,
+		FlushTime: time.Hour,
+		Retry:     RetryConfig{InitialInterval: 10 * time.Millisecond, MaxInterval: 50 * time.Millisecond, MaxElapsedTime: time.Hour},
+	}, nopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// run is stuck retrying the first entry, so the rest fill input and the
+	// last Write blocks, like the collector's drain with an uncancellable ctx
+	writeErr := make(chan error, 1)
+	go func() {
+		entries := make([]*domain.LogEntry, 0, cap(w.input)+2)
+		for range cap(w.input) + 2 {
+			entry, err := domain.NewLogEntry(time.Now(), "info", "event", "msg", nil)
+			if err != nil {
+				writeErr <- err
+				return
+			}
+			entries = append(entries, entry)
+		}
+		writeErr <- w.Write(context.WithoutCancel(context.Background()), entries)
+	}()
+	for len(w.input) < cap(w.input) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := w.Stop(ctx); err == nil {
+		t.Fatal("Stop succeeded although the endpoint is down")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Stop took %v, want about the 200ms deadline", elapsed)
+	}
+
+	select {
+	case err := <-writeErr:
+		if err != ErrStopped {
+			t.Fatalf("blocked Write returned %v, want ErrStopped", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked Write did not return after Stop")
+	}
+}
@@ -8,6 +8,8 @@ package app
 
 import (
 	"context"
+	"event_exporter/internal/adapters/audit"
+	"event_exporter/internal/adapters/checkpoint"
 	k8sfetcher "event_exporter/internal/adapters/kubernetes"
 	"event_exporter/internal/adapters/victorialogs"
 	"event_exporter/internal/config"
@@ -16,43 +18,109 @@ import (
 	"event_exporter/internal/usecase"
 	"fmt"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 )
 
+// checkpointFlusher is implemented by fetchers that checkpoint their watch
+// position.
+type checkpointFlusher interface {
+	FlushCheckpoint(ctx context.Context)
+}
+
 func Run(ctx context.Context, cfg config.Config) error {
 	log := logger.New(cfg.Logger.Level)
 
-	restCfg, err := rest.InClusterConfig()
+	store, err := newCheckpointStore(ctx, log, cfg)
 	if err != nil {
-		return fmt.Errorf("app: cannot build in-cluster config; fallback to core/v1: %w", err)
+		return fmt.Errorf("app: failed to init checkpoint store: %w", err)
+	}
+
+	var positions map[string]string
+	if store != nil {
+		positions, err = store.Load(ctx)
+		if err != nil {
+			return fmt.Errorf("app: failed to load checkpoint: %w", err)
+		}
+		log.Info(ctx, "app: resuming from checkpoint", "type", cfg.Checkpoint.Type, "positions", positions)
 	}
 
-	cs, err := kubernetes.NewForConfig(restCfg)
+	clusters, err := clusterConfigs(cfg)
 	if err != nil {
-		return fmt.Errorf("app: cannot create kube client; fallback to core/v1: %w", err)
+		return fmt.Errorf("app: invalid clusters config: %w", err)
 	}
 
-	fetcher, err := chooseFetcher(ctx, log, cfg.Kubernetes.IncludeNamespaces, cfg.Kubernetes.ExcludeNamespaces, cs)
+	var (
+		fetchers  []usecase.EventFetcher
+		enrichers []usecase.Enricher
+		checkers  []httpserver.ReadyChecker
+	)
 
-	if err != nil {
-		return fmt.Errorf("app: failed to init fetcher: %w", err)
+	for _, cluster := range clusters {
+		components, err := newCluster(ctx, log, cfg, cluster, store, positions)
+		if err != nil {
+			return fmt.Errorf("app: cluster %q: failed to init: %w", cluster.ClusterID, err)
+		}
+
+		fetchers = append(fetchers, components.fetchers...)
+		enrichers = append(enrichers, components.enrichers...)
+	}
+
+	if cfg.Audit.Enabled {
+		receiver, err := newAuditReceiver(cfg, log)
+		if err != nil {
+			return fmt.Errorf("app: failed to init audit receiver: %w", err)
+		}
+		fetchers = append(fetchers, receiver)
+	}
+
+	for _, f := range fetchers {
+		if rc, ok := f.(httpserver.ReadyChecker); ok {
+			checkers = append(checkers, rc)
+		}
 	}
 
 	victoriaLogConfig := victorialogs.VictoriaLogsConfig{
-		Enabled:      cfg.VictoriaLogs.Enabled,
-		Endpoint:     cfg.VictoriaLogs.Endpoint,
-		ClusterID:    cfg.VictoriaLogs.ClusterID,
-		AccountID:    cfg.VictoriaLogs.AccountID,
-		ProjectID:    cfg.VictoriaLogs.ProjectID,
-		BatchSize:    cfg.VictoriaLogs.BatchSize,
-		FlushTime:    cfg.VictoriaLogs.FlushTime,
-		ExtraFields:  cfg.VictoriaLogs.ExtraFields,
-		Timeout:      cfg.VictoriaLogs.Timeout,
-		StreamFields: cfg.VictoriaLogs.StreamFields,
+		Enabled:          cfg.VictoriaLogs.Enabled,
+		Endpoint:         cfg.VictoriaLogs.Endpoint,
+		ClusterID:        cfg.VictoriaLogs.ClusterID,
+		AccountID:        cfg.VictoriaLogs.AccountID,
+		ProjectID:        cfg.VictoriaLogs.ProjectID,
+		BatchSize:        cfg.VictoriaLogs.BatchSize,
+		FlushTime:        cfg.VictoriaLogs.FlushTime,
+		ExtraFields:      cfg.VictoriaLogs.ExtraFields,
+		Timeout:          cfg.VictoriaLogs.Timeout,
+		StreamFields:     cfg.VictoriaLogs.StreamFields,
+		Compression:      cfg.VictoriaLogs.Compression,
+		CompressionLevel: cfg.VictoriaLogs.CompressionLevel,
+		MaxBatchBytes:    cfg.VictoriaLogs.MaxBatchBytes,
+		Auth: victorialogs.AuthConfig{
+			Username:        cfg.VictoriaLogs.Auth.Username,
+			Password:        cfg.VictoriaLogs.Auth.Password,
+			BearerToken:     cfg.VictoriaLogs.Auth.BearerToken,
+			BearerTokenFile: cfg.VictoriaLogs.Auth.BearerTokenFile,
+			Headers:         cfg.VictoriaLogs.Headers,
+		},
+		TLS: victorialogs.TLSConfig{
+			CAFile:             cfg.VictoriaLogs.TLS.CAFile,
+			CertFile:           cfg.VictoriaLogs.TLS.CertFile,
+			KeyFile:            cfg.VictoriaLogs.TLS.KeyFile,
+			InsecureSkipVerify: cfg.VictoriaLogs.TLS.InsecureSkipVerify,
+		},
+		Retry: victorialogs.RetryConfig{
+			InitialInterval: cfg.VictoriaLogs.Retry.InitialInterval,
+			MaxInterval:     cfg.VictoriaLogs.Retry.MaxInterval,
+			MaxElapsedTime:  cfg.VictoriaLogs.Retry.MaxElapsedTime,
+		},
+		Queue: victorialogs.QueueConfig{
+			Path:    cfg.VictoriaLogs.Queue.Path,
+			MaxSize: cfg.VictoriaLogs.Queue.MaxSize,
+			MaxAge:  cfg.VictoriaLogs.Queue.MaxAge,
+		},
 	}
 
 	var writers []usecase.LogWriter
@@ -62,19 +130,21 @@ func Run(ctx context.Context, cfg config.Config) error {
 		return fmt.Errorf("app: failed to init victorialogs writer: %w", err)
 	}
 
+	var counters []httpserver.CounterSource
 	if victoriaWriter != nil {
 		writers = append(writers, victoriaWriter)
+		counters = append(counters, victoriaWriter)
 	}
 
-	collector := usecase.NewCollector(fetcher, writers, log)
-
-	var ready httpserver.ReadyChecker
-
-	if rc, ok := fetcher.(httpserver.ReadyChecker); ok {
-		ready = rc
+	var dedup *usecase.Deduplicator
+	if cfg.Dedup.Enabled {
+		dedup = usecase.NewDeduplicator(cfg.Dedup.Size, cfg.Dedup.TTL)
 	}
 
-	healthSvs := httpserver.NewHealthServer(cfg.HealthConfig.Port, ready)
+	collector := usecase.NewCollector(fetchers, writers, enrichers, dedup, log)
+	counters = append(counters, collector)
+
+	healthSvs := httpserver.NewHealthServer(cfg.HealthConfig.Port, httpserver.AllReady(checkers...), counters...)
 
 	go func() {
 		if err := healthSvs.Start(); err != nil && err != http.ErrServerClosed {
@@ -82,7 +152,9 @@ func Run(ctx context.Context, cfg config.Config) error {
 		}
 	}()
 
+	collectorDone := make(chan struct{})
 	go func() {
+		defer close(collectorDone)
 		if err := collector.Run(ctx); err != nil && err != context.Canceled {
 			log.Error(ctx, "app: collector stopped", "error", err)
 		}
@@ -90,15 +162,44 @@ func Run(ctx context.Context, cfg config.Config) error {
 
 	<-ctx.Done()
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Drain in order: fetchers stop and the collector forwards what they
+	// already produced, the writer sends its last batch, and only then are
+	// the delivered positions checkpointed.
+	log.Info(context.Background(), "app: shutting down",
+		"timeout", cfg.ShutdownTimeout.String(),
+		"flush_timeout", cfg.ShutdownFlushTimeout.String(),
+	)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
 	if err := healthSvs.Stop(shutdownCtx); err != nil {
 		log.Error(context.Background(), "app: failed to stop health server", "error", err)
 	}
 
+	select {
+	case <-collectorDone:
+	case <-shutdownCtx.Done():
+		log.Warn(context.Background(), "app: collector did not drain before the shutdown deadline")
+	}
+
+	// the writer gets its own budget, so a slow drain cannot eat the time
+	// for the last batch
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), cfg.ShutdownFlushTimeout)
+	defer cancelFlush()
+
 	if victoriaWriter != nil {
-		victoriaWriter.Stop()
+		if err := victoriaWriter.Stop(flushCtx); err != nil {
+			log.Error(context.Background(), "app: failed to flush victorialogs writer", "error", err)
+		}
+	}
+
+	checkpointCtx, cancelCheckpoint := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCheckpoint()
+	for _, f := range fetchers {
+		if cf, ok := f.(checkpointFlusher); ok {
+			cf.FlushCheckpoint(checkpointCtx)
+		}
 	}
 
 	log.Info(context.Background(), "app: shutdown complete")
@@ -109,24 +210,133 @@ func Run(ctx context.Context, cfg config.Config) error {
 func chooseFetcher(
 	ctx context.Context,
 	log logger.Logger,
-	include []string,
-	exclude []string,
+	mode string,
+	eventsAPI string,
+	resync time.Duration,
+	fetcherCfg k8sfetcher.FetcherConfig,
 	client *kubernetes.Clientset,
 ) (usecase.EventFetcher, error) {
 
-	ok, err := supportsEventsV1(client)
+	eventsV1, err := chooseEventsAPI(ctx, log, eventsAPI, fetcherCfg.ClusterID, client)
 	if err != nil {
-		log.Warn(ctx, "app: events API detection failed; fallback to core/v1", "error", err)
-		return k8sfetcher.NewFetcher(log, include, exclude, client)
+		return nil, err
 	}
 
-	if !ok {
-		log.Info(ctx, "app: events.k8s.io/v1 not available; using core/v1/events")
-		return k8sfetcher.NewFetcher(log, include, exclude, client)
+	log.Info(ctx, "app: fields not exported with the selected events API",
+		"cluster", fetcherCfg.ClusterID,
+		"lost_fields", k8sfetcher.LostFields(eventsV1),
+	)
+
+	switch strings.ToLower(mode) {
+	case "", "watch":
+		if eventsV1 {
+			return k8sfetcher.NewFetcherV1(log, fetcherCfg, client)
+		}
+		return k8sfetcher.NewFetcher(log, fetcherCfg, client)
+	case "informer":
+		log.Info(ctx, "app: using informer-based fetcher", "resync_period", resync.String())
+		if fetcherCfg.Checkpoint != nil {
+			log.Warn(ctx, "app: checkpoint is not supported by the informer fetcher; it will be ignored")
+		}
+		return k8sfetcher.NewInformerFetcher(log, fetcherCfg, eventsV1, resync, client)
+	default:
+		return nil, fmt.Errorf("unknown fetcher %q", mode)
 	}
+}
 
-	log.Info(ctx, "app: using events.k8s.io/v1 API for event collection")
-	return k8sfetcher.NewFetcherV1(log, include, exclude, client)
+// chooseEventsAPI reports whether events.k8s.io/v1 should be used. "auto"
+// prefers it and falls back to core/v1; a forced version that the cluster
+// does not serve is an error.
+func chooseEventsAPI(ctx context.Context, log logger.Logger, api string, clusterID string, client *kubernetes.Clientset) (bool, error) {
+	switch strings.ToLower(api) {
+	case "", "auto":
+		ok, err := supportsEventsV1(client)
+		switch {
+		case err != nil:
+			log.Warn(ctx, "app: events API detection failed; fallback to core/v1", "cluster", clusterID, "error", err)
+			return false, nil
+		case !ok:
+			log.Info(ctx, "app: events.k8s.io/v1 not available; using core/v1/events", "cluster", clusterID)
+			return false, nil
+		default:
+			log.Info(ctx, "app: using events.k8s.io/v1 API for event collection", "cluster", clusterID)
+			return true, nil
+		}
+	case "core/v1", "v1":
+		if err := requireEventsResource(client, "v1"); err != nil {
+			return false, err
+		}
+		log.Info(ctx, "app: using core/v1/events (forced by config)", "cluster", clusterID)
+		return false, nil
+	case "events.k8s.io/v1":
+		if err := requireEventsResource(client, "events.k8s.io/v1"); err != nil {
+			return false, err
+		}
+		log.Info(ctx, "app: using events.k8s.io/v1 API (forced by config)", "cluster", clusterID)
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown events_api %q; expected auto, core/v1 or events.k8s.io/v1", api)
+	}
+}
+
+// requireEventsResource fails unless the API server serves events in groupVersion.
+func requireEventsResource(dc discovery.DiscoveryInterface, groupVersion string) error {
+	resources, err := dc.ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		return fmt.Errorf("events_api %s is not available: %w", groupVersion, err)
+	}
+
+	for _, r := range resources.APIResources {
+		if r.Name == "events" {
+			return nil
+		}
+	}
+	return fmt.Errorf("events_api %s is not available: no events resource", groupVersion)
+}
+
+// newCheckpointStore builds the configured store. ConfigMap and Lease stores
+// live in the cluster described by the kubernetes section, which is where
+// KENT itself runs when collecting from several clusters.
+func newCheckpointStore(ctx context.Context, log logger.Logger, cfg config.Config) (k8sfetcher.CheckpointStore, error) {
+	kind := strings.ToLower(cfg.Checkpoint.Type)
+
+	switch kind {
+	case "", "none":
+		return nil, nil
+	case "file":
+		return checkpoint.NewFileStore(cfg.Checkpoint.Path)
+	case "configmap", "lease":
+	default:
+		return nil, fmt.Errorf("unknown checkpoint type %q", cfg.Checkpoint.Type)
+	}
+
+	client, _, err := connect(ctx, log, k8sfetcher.ClientConfig{
+		Kubeconfig: cfg.Kubernetes.Kubeconfig,
+		Context:    cfg.Kubernetes.Context,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := cfg.Checkpoint.Namespace
+	if namespace == "" {
+		namespace = podNamespace()
+	}
+
+	if kind == "configmap" {
+		return checkpoint.NewConfigMapStore(client, namespace, cfg.Checkpoint.Name)
+	}
+	return checkpoint.NewLeaseStore(client, namespace, cfg.Checkpoint.Name)
+}
+
+// podNamespace returns the namespace KENT runs in, as mounted into every pod
+// with a service account token.
+func podNamespace() string {
+	data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
 }
 
 func supportsEventsV1(dc discovery.DiscoveryInterface) (bool, error) {
@@ -146,3 +356,26 @@ func supportsEventsV1(dc discovery.DiscoveryInterface) (bool, error) {
 	}
 	return false, nil
 }
+
+func newAuditReceiver(cfg config.Config, log logger.Logger) (*audit.Receiver, error) {
+	clusterID := cfg.Audit.ClusterID
+	if clusterID == "" {
+		clusterID = cfg.VictoriaLogs.ClusterID
+	}
+
+	return audit.NewReceiver(audit.Config{
+		Port:         cfg.Audit.Port,
+		Path:         cfg.Audit.Path,
+		TLSCertFile:  cfg.Audit.TLSCertFile,
+		TLSKeyFile:   cfg.Audit.TLSKeyFile,
+		ClientCAFile: cfg.Audit.ClientCAFile,
+		BearerToken:  cfg.Audit.BearerToken,
+		Insecure:     cfg.Audit.Insecure,
+		ClusterID:    clusterID,
+		Stages:       cfg.Audit.Stages,
+		Verbs:        cfg.Audit.Verbs,
+		Resources:    cfg.Audit.Resources,
+		Users:        cfg.Audit.Users,
+		ExcludeUsers: cfg.Audit.ExcludeUsers,
+	}, log)
+}
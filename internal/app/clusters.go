@@ -0,0 +1,263 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package app
+
+import (
+	"context"
+	"errors"
+	k8sfetcher "event_exporter/internal/adapters/kubernetes"
+	"event_exporter/internal/config"
+	"event_exporter/internal/pkg/logger"
+	"event_exporter/internal/usecase"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+)
+
+// clusterConfigs returns the configured clusters, or the single cluster
+// described by the kubernetes section with the VictoriaLogs cluster ID.
+func clusterConfigs(cfg config.Config) ([]config.Cluster, error) {
+	if len(cfg.Clusters) == 0 {
+		cluster := config.Cluster{
+			ClusterID:         cfg.VictoriaLogs.ClusterID,
+			Kubeconfig:        cfg.Kubernetes.Kubeconfig,
+			Context:           cfg.Kubernetes.Context,
+			QPS:               cfg.Kubernetes.QPS,
+			Burst:             cfg.Kubernetes.Burst,
+			IncludeNamespaces: cfg.Kubernetes.IncludeNamespaces,
+			ExcludeNamespaces: cfg.Kubernetes.ExcludeNamespaces,
+
+			IncludeNamespaceSelector: cfg.Kubernetes.IncludeNamespaceSelector,
+			ExcludeNamespaceSelector: cfg.Kubernetes.ExcludeNamespaceSelector,
+		}
+		if err := checkNamespaceAccess(cfg, cluster); err != nil {
+			return nil, err
+		}
+		return []config.Cluster{cluster}, nil
+	}
+
+	ids := make(map[string]struct{}, len(cfg.Clusters))
+	for _, c := range cfg.Clusters {
+		if c.ClusterID == "" {
+			return nil, fmt.Errorf("cluster_id is required for every cluster")
+		}
+		if _, ok := ids[c.ClusterID]; ok {
+			return nil, fmt.Errorf("duplicate cluster_id %q", c.ClusterID)
+		}
+		ids[c.ClusterID] = struct{}{}
+		if err := checkNamespaceAccess(cfg, c); err != nil {
+			return nil, fmt.Errorf("cluster %q: %w", c.ClusterID, err)
+		}
+	}
+	return cfg.Clusters, nil
+}
+
+// checkNamespaceAccess rejects features that read Namespace objects when
+// include_namespaces limits KENT to namespaced Roles: the namespace cache
+// could never sync and the startup would hang.
+func checkNamespaceAccess(cfg config.Config, c config.Cluster) error {
+	if len(c.IncludeNamespaces) == 0 {
+		return nil
+	}
+	if c.IncludeNamespaceSelector != "" || c.ExcludeNamespaceSelector != "" {
+		return errors.New("include_namespace_selector and exclude_namespace_selector need cluster-wide access to namespaces and cannot be combined with include_namespaces")
+	}
+	if len(cfg.Enrichment.NamespaceLabels) > 0 || len(cfg.Enrichment.NamespaceAnnotations) > 0 {
+		return errors.New("enrichment.namespace_labels and enrichment.namespace_annotations need cluster-wide access to namespaces and cannot be combined with include_namespaces")
+	}
+	return nil
+}
+
+func connect(ctx context.Context, log logger.Logger, clientCfg k8sfetcher.ClientConfig) (*kubernetes.Clientset, *rest.Config, error) {
+	restCfg, source, err := k8sfetcher.NewRestConfig(clientCfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot build kube client config: %w", err)
+	}
+	log.Info(ctx, "app: connecting to kubernetes API", "source", source, "host", restCfg.Host)
+
+	cs, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot create kube client: %w", err)
+	}
+	return cs, restCfg, nil
+}
+
+// clusterComponents is what one cluster contributes to the collector.
+type clusterComponents struct {
+	fetchers  []usecase.EventFetcher
+	enrichers []usecase.Enricher
+}
+
+// newCluster connects to one cluster and builds its event fetchers and
+// enrichers. Metadata caches are synced before it returns.
+func newCluster(
+	ctx context.Context,
+	log logger.Logger,
+	cfg config.Config,
+	cluster config.Cluster,
+	store k8sfetcher.CheckpointStore,
+	positions map[string]string,
+) (clusterComponents, error) {
+	var components clusterComponents
+
+	cs, restCfg, err := connect(ctx, log, k8sfetcher.ClientConfig{
+		Kubeconfig: cluster.Kubeconfig,
+		Context:    cluster.Context,
+		Server:     cluster.Server,
+		TokenFile:  cluster.TokenFile,
+		CAFile:     cluster.CAFile,
+		QPS:        cluster.QPS,
+		Burst:      cluster.Burst,
+	})
+	if err != nil {
+		return components, err
+	}
+
+	// the metadata cache backs both the namespace selectors and enrichment
+	var metaCache *k8sfetcher.MetadataCache
+	if enrichmentEnabled(cfg) || cluster.IncludeNamespaceSelector != "" || cluster.ExcludeNamespaceSelector != "" {
+		metaClient, err := metadata.NewForConfig(restCfg)
+		if err != nil {
+			return components, fmt.Errorf("cannot create metadata client: %w", err)
+		}
+		metaCache = k8sfetcher.NewMetadataCache(metaClient, cluster.IncludeNamespaces, cfg.Enrichment.ResyncPeriod, log)
+	}
+
+	nsFilter, err := k8sfetcher.NewNamespaceFilter(metaCache, cluster.IncludeNamespaceSelector, cluster.ExcludeNamespaceSelector)
+	if err != nil {
+		return components, err
+	}
+
+	fetcherCfg := k8sfetcher.FetcherConfig{
+		ClusterID:         cluster.ClusterID,
+		IncludeNamespaces: cluster.IncludeNamespaces,
+		ExcludeNamespaces: cluster.ExcludeNamespaces,
+		NamespaceFilter:   nsFilter,
+		ExportDeleted:     cfg.Kubernetes.ExportDeleted,
+		FieldSelectors: k8sfetcher.EventFieldSelectors{
+			InvolvedObjectKind: cfg.Kubernetes.FieldSelectors.InvolvedObjectKind,
+			Type:               cfg.Kubernetes.FieldSelectors.Type,
+			Reason:             cfg.Kubernetes.FieldSelectors.Reason,
+			Source:             cfg.Kubernetes.FieldSelectors.Source,
+		},
+		Checkpoint:         store,
+		CheckpointInterval: cfg.Checkpoint.Interval,
+		Positions:          positions,
+	}
+
+	eventsAPI := cfg.Kubernetes.EventsAPI
+	if cluster.EventsAPI != "" {
+		eventsAPI = cluster.EventsAPI
+	}
+
+	fetcher, err := chooseFetcher(ctx, log, cfg.Kubernetes.Fetcher, eventsAPI, cfg.Kubernetes.ResyncPeriod, fetcherCfg, cs)
+	if err != nil {
+		return components, err
+	}
+	components.fetchers = append(components.fetchers, fetcher)
+
+	if cfg.Sources.PodStatus {
+		components.fetchers = append(components.fetchers, k8sfetcher.NewPodStatusFetcher(log, fetcherCfg, cfg.Kubernetes.ResyncPeriod, cs))
+	}
+	if cfg.Sources.Nodes {
+		components.fetchers = append(components.fetchers, k8sfetcher.NewNodeConditionFetcher(log, cluster.ClusterID, cfg.Kubernetes.ResyncPeriod, cs))
+	}
+	if cfg.Sources.Rollouts {
+		components.fetchers = append(components.fetchers, k8sfetcher.NewRolloutFetcher(log, fetcherCfg, cfg.Kubernetes.ResyncPeriod, cs))
+	}
+	if cfg.Sources.Jobs {
+		components.fetchers = append(components.fetchers, k8sfetcher.NewJobFetcher(log, fetcherCfg, cfg.Kubernetes.ResyncPeriod, cs))
+	}
+	if len(cfg.Sources.Conditions) > 0 {
+		dynClient, err := dynamic.NewForConfig(restCfg)
+		if err != nil {
+			return components, fmt.Errorf("cannot create dynamic client: %w", err)
+		}
+		resources := make([]schema.GroupVersionResource, 0, len(cfg.Sources.Conditions))
+		for _, r := range cfg.Sources.Conditions {
+			resources = append(resources, schema.GroupVersionResource{Group: r.Group, Version: r.Version, Resource: r.Resource})
+		}
+		components.fetchers = append(components.fetchers, k8sfetcher.NewConditionFetcher(
+			log,
+			fetcherCfg,
+			resources,
+			cfg.Kubernetes.ResyncPeriod,
+			dynClient,
+			cs.Discovery(),
+		))
+	}
+
+	if metaCache == nil {
+		return components, nil
+	}
+
+	if cfg.Enrichment.Owners {
+		components.enrichers = append(components.enrichers, k8sfetcher.NewOwnerEnricher(cluster.ClusterID, metaCache))
+	}
+
+	if len(cfg.Enrichment.Labels) > 0 || len(cfg.Enrichment.Annotations) > 0 {
+		labels, err := k8sfetcher.NewLabelEnricher(
+			ctx, log,
+			cluster.ClusterID,
+			metaCache,
+			cs.Discovery(),
+			cfg.Enrichment.Kinds,
+			cfg.Enrichment.Labels,
+			cfg.Enrichment.Annotations,
+		)
+		if err != nil {
+			return components, err
+		}
+		components.enrichers = append(components.enrichers, labels)
+	}
+
+	if len(cfg.Enrichment.NamespaceLabels) > 0 || len(cfg.Enrichment.NamespaceAnnotations) > 0 {
+		components.enrichers = append(components.enrichers, k8sfetcher.NewNamespaceEnricher(
+			cluster.ClusterID,
+			metaCache,
+			cfg.Enrichment.NamespaceLabels,
+			cfg.Enrichment.NamespaceAnnotations,
+		))
+	}
+
+	var nodes *k8sfetcher.NodeEnricher
+	if cfg.Enrichment.Nodes {
+		nodes = k8sfetcher.NewNodeEnricher(
+			ctx, log,
+			cluster.ClusterID,
+			metaCache,
+			cs,
+			cluster.IncludeNamespaces,
+			cfg.Enrichment.ResyncPeriod,
+			cfg.Enrichment.NodeLabels,
+		)
+		components.enrichers = append(components.enrichers, nodes)
+	}
+
+	if !metaCache.Start(ctx) {
+		return components, fmt.Errorf("metadata caches did not sync: %w", ctx.Err())
+	}
+	if nodes != nil && !nodes.Start(ctx) {
+		return components, fmt.Errorf("pod cache did not sync: %w", ctx.Err())
+	}
+	log.Info(ctx, "app: metadata caches synced", "cluster", cluster.ClusterID)
+
+	return components, nil
+}
+
+func enrichmentEnabled(cfg config.Config) bool {
+	return cfg.Enrichment.Owners ||
+		len(cfg.Enrichment.Labels) > 0 ||
+		len(cfg.Enrichment.Annotations) > 0 ||
+		len(cfg.Enrichment.NamespaceLabels) > 0 ||
+		len(cfg.Enrichment.NamespaceAnnotations) > 0 ||
+		cfg.Enrichment.Nodes
+}
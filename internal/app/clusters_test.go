@@ -0,0 +1,42 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package app
+
+import (
+	"event_exporter/internal/config"
+	"testing"
+)
+
+func TestClusterConfigsRejectsNamespaceReadsWithIncludeNamespaces(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*config.Config)
+		wantErr bool
+	}{
+		{name: "include only", modify: func(*config.Config) {}},
+		{name: "selector", modify: func(c *config.Config) { c.Kubernetes.IncludeNamespaceSelector = "team=a" }, wantErr: true},
+		{name: "namespace labels", modify: func(c *config.Config) { c.Enrichment.NamespaceLabels = []string{"team"} }, wantErr: true},
+		{name: "per-cluster selector", modify: func(c *config.Config) {
+			c.Clusters = []config.Cluster{{ClusterID: "a", IncludeNamespaces: []string{"apps"}, ExcludeNamespaceSelector: "tier=test"}}
+		}, wantErr: true},
+		{name: "selector without include", modify: func(c *config.Config) {
+			c.Kubernetes.IncludeNamespaces = nil
+			c.Kubernetes.IncludeNamespaceSelector = "team=a"
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config.Config
+			cfg.Kubernetes.IncludeNamespaces = []string{"apps"}
+			tt.modify(&cfg)
+			_, err := clusterConfigs(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("clusterConfigs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
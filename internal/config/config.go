@@ -13,11 +13,129 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
+// Cluster is one Kubernetes API server KENT collects events from.
+// Credentials come either from a kubeconfig/context or from server, token
+// file and CA bundle.
+type Cluster struct {
+	ClusterID         string   `yaml:"cluster_id"`
+	Kubeconfig        string   `yaml:"kubeconfig"`
+	Context           string   `yaml:"context"`
+	Server            string   `yaml:"server"`
+	TokenFile         string   `yaml:"token_file"`
+	CAFile            string   `yaml:"ca_file"`
+	QPS               float32  `yaml:"qps"`
+	Burst             int      `yaml:"burst"`
+	EventsAPI         string   `yaml:"events_api"`
+	IncludeNamespaces []string `yaml:"include_namespaces"`
+	ExcludeNamespaces []string `yaml:"exclude_namespaces"`
+	// IncludeNamespaceSelector and ExcludeNamespaceSelector are label
+	// selectors matched against the namespace of each event.
+	IncludeNamespaceSelector string `yaml:"include_namespace_selector"`
+	ExcludeNamespaceSelector string `yaml:"exclude_namespace_selector"`
+}
+
+// Resource names an API resource, e.g. cert-manager.io/v1 certificates.
+type Resource struct {
+	Group    string `yaml:"group"`
+	Version  string `yaml:"version"`
+	Resource string `yaml:"resource"`
+}
+
 type Config struct {
+	// Clusters, when set, replaces the single cluster described by the
+	// kubernetes section; settings not listed in Cluster are shared.
+	Clusters   []Cluster `yaml:"clusters"`
 	Kubernetes struct {
+		Kubeconfig        string   `yaml:"kubeconfig" env:"K8S_KUBECONFIG"`
+		Context           string   `yaml:"context" env:"K8S_CONTEXT"`
+		QPS               float32  `yaml:"qps" env:"K8S_QPS"`
+		Burst             int      `yaml:"burst" env:"K8S_BURST"`
 		IncludeNamespaces []string `yaml:"include_namespaces" env:"K8S_INCLUDE_NAMESPACES" env-separator:","`
 		ExcludeNamespaces []string `yaml:"exclude_namespaces" env:"K8S_EXCLUDE_NAMESPACES" env-separator:","`
+		// Namespace label selectors, e.g. "owner-team in (payments,search)".
+		IncludeNamespaceSelector string `yaml:"include_namespace_selector" env:"K8S_INCLUDE_NAMESPACE_SELECTOR"`
+		ExcludeNamespaceSelector string `yaml:"exclude_namespace_selector" env:"K8S_EXCLUDE_NAMESPACE_SELECTOR"`
+		ExportDeleted            bool   `yaml:"export_deleted" env:"K8S_EXPORT_DELETED"`
+		FieldSelectors           struct {
+			InvolvedObjectKind string `yaml:"involved_object_kind" env:"K8S_SELECTOR_INVOLVED_OBJECT_KIND"`
+			Type               string `yaml:"type" env:"K8S_SELECTOR_TYPE"`
+			Reason             string `yaml:"reason" env:"K8S_SELECTOR_REASON"`
+			Source             string `yaml:"source" env:"K8S_SELECTOR_SOURCE"`
+		} `yaml:"field_selectors"`
+		// EventsAPI is "auto", "core/v1" or "events.k8s.io/v1".
+		EventsAPI string `yaml:"events_api" env:"K8S_EVENTS_API" env-default:"auto"`
+		// Fetcher selects the implementation: "watch" (default) or "informer".
+		Fetcher      string        `yaml:"fetcher" env:"K8S_FETCHER" env-default:"watch"`
+		ResyncPeriod time.Duration `yaml:"resync_period" env:"K8S_RESYNC_PERIOD"`
 	} `yaml:"kubernetes"`
+	Checkpoint struct {
+		Type      string        `yaml:"type" env:"CHECKPOINT_TYPE"`
+		Path      string        `yaml:"path" env:"CHECKPOINT_PATH" env-default:"/var/lib/kent/checkpoint.json"`
+		Namespace string        `yaml:"namespace" env:"CHECKPOINT_NAMESPACE"`
+		Name      string        `yaml:"name" env:"CHECKPOINT_NAME" env-default:"kent-checkpoint"`
+		Interval  time.Duration `yaml:"interval" env:"CHECKPOINT_INTERVAL" env-default:"10s"`
+	} `yaml:"checkpoint"`
+	Enrichment struct {
+		// Owners adds k8s.owner.* and k8s.workload.* resolved through ownerReferences.
+		Owners bool `yaml:"owners" env:"ENRICH_OWNERS"`
+		// Labels and Annotations are copied from the involved object when its
+		// kind is listed in Kinds.
+		Labels      []string `yaml:"labels" env:"ENRICH_LABELS" env-separator:","`
+		Annotations []string `yaml:"annotations" env:"ENRICH_ANNOTATIONS" env-separator:","`
+		Kinds       []string `yaml:"kinds" env:"ENRICH_KINDS" env-separator:"," env-default:"Pod,Deployment,StatefulSet,DaemonSet,ReplicaSet,Job,CronJob,Node,Service,PersistentVolumeClaim"`
+		// NamespaceLabels and NamespaceAnnotations are copied from the
+		// namespace of the event.
+		NamespaceLabels      []string `yaml:"namespace_labels" env:"ENRICH_NAMESPACE_LABELS" env-separator:","`
+		NamespaceAnnotations []string `yaml:"namespace_annotations" env:"ENRICH_NAMESPACE_ANNOTATIONS" env-separator:","`
+		// Nodes adds the node of pod events with its zone, region and instance
+		// type; NodeLabels are extra node labels, e.g. the node pool.
+		Nodes        bool          `yaml:"nodes" env:"ENRICH_NODES"`
+		NodeLabels   []string      `yaml:"node_labels" env:"ENRICH_NODE_LABELS" env-separator:","`
+		ResyncPeriod time.Duration `yaml:"resync_period" env:"ENRICH_RESYNC_PERIOD"`
+	} `yaml:"enrichment"`
+	// Sources are optional producers of synthetic events that run next to
+	// the events fetcher of every cluster.
+	Sources struct {
+		// PodStatus reports container terminations, restarts and waiting
+		// reasons as log type "pod_status".
+		PodStatus bool `yaml:"pod_status" env:"SOURCE_POD_STATUS"`
+		// Nodes reports node condition, taint and cordon changes as log
+		// type "node".
+		Nodes bool `yaml:"nodes" env:"SOURCE_NODES"`
+		// Conditions lists resources, typically custom resources, whose
+		// .status.conditions changes are reported as log type "condition".
+		Conditions []Resource `yaml:"conditions"`
+		// Rollouts reports Deployment, StatefulSet and DaemonSet rollouts
+		// (started, progressing, completed, failed) as log type "rollout".
+		Rollouts bool `yaml:"rollouts" env:"SOURCE_ROLLOUTS"`
+		// Jobs reports every finished Job as log type "job".
+		Jobs bool `yaml:"jobs" env:"SOURCE_JOBS"`
+	} `yaml:"sources"`
+	// Audit runs an HTTP receiver for the API server audit webhook backend.
+	Audit struct {
+		Enabled     bool   `yaml:"enabled" env:"AUDIT_ENABLED"`
+		Port        int    `yaml:"port" env:"AUDIT_PORT" env-default:"8443"`
+		Path        string `yaml:"path" env:"AUDIT_PATH" env-default:"/audit"`
+		TLSCertFile string `yaml:"tls_cert_file" env:"AUDIT_TLS_CERT_FILE"`
+		TLSKeyFile  string `yaml:"tls_key_file" env:"AUDIT_TLS_KEY_FILE"`
+		// ClientCAFile enforces client certificates, BearerToken a token;
+		// a plain HTTP listener needs Insecure.
+		ClientCAFile string `yaml:"client_ca_file" env:"AUDIT_CLIENT_CA_FILE"`
+		BearerToken  string `yaml:"bearer_token" env:"AUDIT_BEARER_TOKEN"`
+		Insecure     bool   `yaml:"insecure" env:"AUDIT_INSECURE"`
+		// ClusterID defaults to victoria_logs.cluster_id.
+		ClusterID    string   `yaml:"cluster_id" env:"AUDIT_CLUSTER_ID"`
+		Stages       []string `yaml:"stages" env:"AUDIT_STAGES" env-separator:","`
+		Verbs        []string `yaml:"verbs" env:"AUDIT_VERBS" env-separator:","`
+		Resources    []string `yaml:"resources" env:"AUDIT_RESOURCES" env-separator:","`
+		Users        []string `yaml:"users" env:"AUDIT_USERS" env-separator:","`
+		ExcludeUsers []string `yaml:"exclude_users" env:"AUDIT_EXCLUDE_USERS" env-separator:","`
+	} `yaml:"audit"`
+	Dedup struct {
+		Enabled bool          `yaml:"enabled" env:"DEDUP_ENABLED" env-default:"true"`
+		Size    int           `yaml:"size" env:"DEDUP_SIZE" env-default:"10000"`
+		TTL     time.Duration `yaml:"ttl" env:"DEDUP_TTL" env-default:"1h"`
+	} `yaml:"dedup"`
 	VictoriaLogs struct {
 		Enabled      bool              `yaml:"enabled" env:"VL_ENABLED"`
 		Endpoint     string            `yaml:"endpoint" env:"VL_ENDPOINT"`
@@ -29,6 +147,40 @@ type Config struct {
 		AccountID    string            `yaml:"account_id" env:"VL_ACCOUNT_ID"`
 		ProjectID    string            `yaml:"project_id" env:"VL_PROJECT_ID"`
 		StreamFields []string          `yaml:"stream_fields" env:"VL_STREAM_FIELDS" env-separator:","`
+		// Compression of request bodies: none, gzip or zstd; level 0 is the
+		// algorithm default. max_batch_bytes caps the uncompressed body.
+		Compression      string `yaml:"compression" env:"VL_COMPRESSION" env-default:"none"`
+		CompressionLevel int    `yaml:"compression_level" env:"VL_COMPRESSION_LEVEL"`
+		MaxBatchBytes    int    `yaml:"max_batch_bytes" env:"VL_MAX_BATCH_BYTES" env-default:"4194304"`
+		// Auth for a protected endpoint such as vmauth: basic auth or a
+		// bearer token, inline or from a file that is re-read on rotation.
+		Auth struct {
+			Username        string `yaml:"username" env:"VL_USERNAME"`
+			Password        string `yaml:"password" env:"VL_PASSWORD"`
+			BearerToken     string `yaml:"bearer_token" env:"VL_BEARER_TOKEN"`
+			BearerTokenFile string `yaml:"bearer_token_file" env:"VL_BEARER_TOKEN_FILE"`
+		} `yaml:"auth"`
+		Headers map[string]string `yaml:"headers" env:"VL_HEADERS"`
+		TLS     struct {
+			CAFile             string `yaml:"ca_file" env:"VL_TLS_CA_FILE"`
+			CertFile           string `yaml:"cert_file" env:"VL_TLS_CERT_FILE"`
+			KeyFile            string `yaml:"key_file" env:"VL_TLS_KEY_FILE"`
+			InsecureSkipVerify bool   `yaml:"insecure_skip_verify" env:"VL_TLS_INSECURE_SKIP_VERIFY"`
+		} `yaml:"tls"`
+		// Retry of failed batches; max_elapsed_time 0 drops a batch on the
+		// first failure.
+		Retry struct {
+			InitialInterval time.Duration `yaml:"initial_interval" env:"VL_RETRY_INITIAL_INTERVAL" env-default:"1s"`
+			MaxInterval     time.Duration `yaml:"max_interval" env:"VL_RETRY_MAX_INTERVAL" env-default:"30s"`
+			MaxElapsedTime  time.Duration `yaml:"max_elapsed_time" env:"VL_RETRY_MAX_ELAPSED_TIME" env-default:"5m"`
+		} `yaml:"retry"`
+		// Queue buffers batches on disk (e.g. a PVC) so they survive an
+		// outage and a restart; an empty path keeps batches in memory.
+		Queue struct {
+			Path    string        `yaml:"path" env:"VL_QUEUE_PATH"`
+			MaxSize int64         `yaml:"max_size" env:"VL_QUEUE_MAX_SIZE" env-default:"536870912"`
+			MaxAge  time.Duration `yaml:"max_age" env:"VL_QUEUE_MAX_AGE" env-default:"24h"`
+		} `yaml:"queue"`
 	} `yaml:"victoria_logs"`
 	HealthConfig struct {
 		Port int `yaml:"port" env:"HEALTH_PORT" env-default:"8080"`
@@ -36,6 +188,11 @@ type Config struct {
 	Logger struct {
 		Level string `yaml:"level" env:"LOG_LEVEL" env-default:"info"`
 	} `yaml:"logger"`
+	// ShutdownTimeout bounds the drain of fetchers and collector on SIGTERM,
+	// ShutdownFlushTimeout the writer's last batch afterwards. Keep both
+	// plus 5s for the checkpoints below terminationGracePeriodSeconds.
+	ShutdownTimeout      time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" env-default:"10s"`
+	ShutdownFlushTimeout time.Duration `yaml:"shutdown_flush_timeout" env:"SHUTDOWN_FLUSH_TIMEOUT" env-default:"10s"`
 }
 
 func Load(cfg *Config) error {
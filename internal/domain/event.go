@@ -20,9 +20,24 @@ var (
 )
 
 type ObjectRef struct {
-	Kind      string
-	Name      string
-	Namespace string
+	Kind       string
+	Name       string
+	Namespace  string
+	UID        string
+	APIVersion string
+	// FieldPath points into the object, e.g. spec.containers{app}.
+	FieldPath string
+}
+
+// EventDetails are the optional parts of an event that not every source
+// fills in.
+type EventDetails struct {
+	Action            string
+	Related           *ObjectRef
+	ReportingInstance string
+	// SourceHost is the node of the reporting kubelet (deprecatedSource.host).
+	SourceHost             string
+	SeriesLastObservedTime *time.Time
 }
 
 type Event struct {
@@ -37,6 +52,12 @@ type Event struct {
 	eventTime      time.Time
 	lastTimestamp  *time.Time
 	count          int32
+	details        EventDetails
+	logType        string
+	attributes     map[string]string
+	watchType      string
+	clusterID      string
+	onDelivered    func()
 }
 
 func NewEvent(
@@ -92,3 +113,51 @@ func (e *Event) FirstTimestamp() time.Time { return e.eventTime }
 func (e *Event) EventTime() time.Time      { return e.eventTime }
 func (e *Event) LastTimestamp() *time.Time { return e.lastTimestamp }
 func (e *Event) Count() int32              { return e.count }
+func (e *Event) Details() EventDetails     { return e.details }
+func (e *Event) WatchType() string         { return e.watchType }
+
+// LogType is the log type the event is exported as; Kubernetes Events are
+// "event", synthetic events set their own.
+func (e *Event) LogType() string {
+	if e.logType == "" {
+		return "event"
+	}
+	return e.logType
+}
+
+// Attributes are source-specific fields exported along with the event.
+func (e *Event) Attributes() map[string]string { return e.attributes }
+func (e *Event) ClusterID() string             { return e.clusterID }
+
+// SetDetails attaches the optional event fields.
+func (e *Event) SetDetails(d EventDetails) { e.details = d }
+
+// SetLogType overrides the log type of a synthetic event.
+func (e *Event) SetLogType(t string) { e.logType = t }
+
+// SetAttribute adds a source-specific field.
+func (e *Event) SetAttribute(key, value string) {
+	if e.attributes == nil {
+		e.attributes = make(map[string]string)
+	}
+	e.attributes[key] = value
+}
+
+// SetWatchType records the watch notification (ADDED, MODIFIED, DELETED)
+// the event arrived with.
+func (e *Event) SetWatchType(t string) { e.watchType = t }
+
+// SetClusterID records which cluster the event was collected from.
+func (e *Event) SetClusterID(id string) { e.clusterID = id }
+
+// SetDeliveryHook registers fn to be called once the event has been
+// accepted by the storage backend, or deliberately not exported (e.g. as a
+// duplicate). Sources use it to commit their position.
+func (e *Event) SetDeliveryHook(fn func()) { e.onDelivered = fn }
+
+// Delivered runs the delivery hook, if any.
+func (e *Event) Delivered() {
+	if e.onDelivered != nil {
+		e.onDelivered()
+	}
+}
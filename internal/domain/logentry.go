@@ -22,6 +22,8 @@ type LogEntry struct {
 	logType   string
 	message   string
 	fields    map[string]string
+
+	onDelivered func()
 }
 
 func NewLogEntry(
@@ -71,3 +73,16 @@ func (l *LogEntry) LogType() string {
 func (l *LogEntry) Fields() map[string]string {
 	return l.fields
 }
+
+// SetDeliveryHook registers fn to be called once a writer has confirmed the
+// entry was stored.
+func (l *LogEntry) SetDeliveryHook(fn func()) {
+	l.onDelivered = fn
+}
+
+// Delivered runs the delivery hook, if any.
+func (l *LogEntry) Delivered() {
+	if l.onDelivered != nil {
+		l.onDelivered()
+	}
+}
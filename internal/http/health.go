@@ -9,19 +9,43 @@ package httpserver
 import (
 	"context"
 	"fmt"
+	"maps"
 	"net/http"
+	"slices"
 )
 
 type ReadyChecker interface {
 	Ready() bool
 }
 
+// AllReady reports ready only when every checker is ready.
+func AllReady(checkers ...ReadyChecker) ReadyChecker {
+	return allReady(checkers)
+}
+
+type allReady []ReadyChecker
+
+func (a allReady) Ready() bool {
+	for _, c := range a {
+		if !c.Ready() {
+			return false
+		}
+	}
+	return len(a) > 0
+}
+
+// CounterSource reports monotonic counters by metric name, served on
+// /metrics in the Prometheus text format.
+type CounterSource interface {
+	Counters() map[string]uint64
+}
+
 type Server struct {
 	srv     *http.Server
 	fetcher ReadyChecker
 }
 
-func NewHealthServer(port int, fetcher ReadyChecker) *Server {
+func NewHealthServer(port int, fetcher ReadyChecker, counters ...CounterSource) *Server {
 	s := &Server{fetcher: fetcher}
 
 	mux := http.NewServeMux()
@@ -39,6 +63,16 @@ func NewHealthServer(port int, fetcher ReadyChecker) *Server {
 		}
 	})
 
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		for _, src := range counters {
+			values := src.Counters()
+			for _, name := range slices.Sorted(maps.Keys(values)) {
+				fmt.Fprintf(w, "# TYPE %s counter\n%s %d\n", name, name, values[name])
+			}
+		}
+	})
+
 	return &Server{
 		srv: &http.Server{
 			Addr:    fmt.Sprintf(":%d", port),
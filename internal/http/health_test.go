@@ -0,0 +1,30 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package httpserver
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+)
+
+type staticCounters map[string]uint64
+
+func (c staticCounters) Counters() map[string]uint64 { return c }
+
+func TestMetrics(t *testing.T) {
+	s := NewHealthServer(0, nil, staticCounters{"b_total": 2, "a_total": 1})
+
+	rec := httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, _ := io.ReadAll(rec.Body)
+	want := "# TYPE a_total counter\na_total 1\n# TYPE b_total counter\nb_total 2\n"
+	if string(body) != want {
+		t.Fatalf("metrics = %q, want %q", body, want)
+	}
+}
@@ -10,6 +10,9 @@ import (
 	"context"
 	"event_exporter/internal/domain"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type EventFetcher interface {
@@ -20,6 +23,12 @@ type LogWriter interface {
 	Write(ctx context.Context, logs []*domain.LogEntry) error
 }
 
+// Enricher adds fields derived from the event, e.g. looked up in a cache of
+// the involved object's cluster, to the log entry fields.
+type Enricher interface {
+	Enrich(ctx context.Context, ev *domain.Event, fields map[string]string)
+}
+
 type Logger interface {
 	Debug(ctx context.Context, msg string, kv ...any)
 	Info(ctx context.Context, msg string, kv ...any)
@@ -28,19 +37,31 @@ type Logger interface {
 }
 
 type Collector struct {
-	fetcher EventFetcher
-	writers []LogWriter
-	logger  Logger
+	fetchers  []EventFetcher
+	writers   []LogWriter
+	enrichers []Enricher
+	dedup     *Deduplicator
+	logger    Logger
+
+	writeFailures atomic.Uint64
 }
 
-func NewCollector(fetcher EventFetcher, writers []LogWriter, logger Logger) *Collector {
+// NewCollector merges the events of all fetchers, runs the enrichers and
+// hands the entries to the writers. dedup may be nil to forward every
+// notification as is.
+func NewCollector(fetchers []EventFetcher, writers []LogWriter, enrichers []Enricher, dedup *Deduplicator, logger Logger) *Collector {
 	return &Collector{
-		fetcher: fetcher,
-		writers: writers,
-		logger:  logger,
+		fetchers:  fetchers,
+		writers:   writers,
+		enrichers: enrichers,
+		dedup:     dedup,
+		logger:    logger,
 	}
 }
 
+// Run forwards events until ctx is cancelled. It then waits for the fetchers
+// to stop and hands the events still buffered to the writers before
+// returning, so a graceful shutdown does not lose them.
 func (c *Collector) Run(ctx context.Context) error {
 
 	if len(c.writers) == 0 {
@@ -49,43 +70,123 @@ func (c *Collector) Run(ctx context.Context) error {
 
 	events := make(chan *domain.Event, 100)
 
+	var wg sync.WaitGroup
+	for _, f := range c.fetchers {
+		wg.Go(func() {
+			if err := f.Stream(ctx, events); err != nil && err != context.Canceled {
+				c.logger.Error(ctx, "usecase:collector: fetcher stream stopped", "error", err)
+			}
+		})
+	}
+
+	stopped := make(chan struct{})
 	go func() {
-		if err := c.fetcher.Stream(ctx, events); err != nil {
-			c.logger.Error(ctx, "usecase:collector: fetcher stream stopped", "error", err)
-		}
+		wg.Wait()
+		close(stopped)
 	}()
 
 	for {
 		select {
 		case <-ctx.Done():
+			c.drain(context.WithoutCancel(ctx), events, stopped)
 			return ctx.Err()
-		case ev, ok := <-events:
-			if !ok {
-				return nil
-			}
+		case ev := <-events:
+			c.forward(ctx, ev, false)
+		}
+	}
+}
 
-			logEntry, err := convertEventToLogEntry(ev)
-			if err != nil {
-				c.logger.Error(ctx, "failed to convert event to log entry", "error", err)
-				continue
-			}
+// Counters exposes the collector counters on the health server's /metrics.
+func (c *Collector) Counters() map[string]uint64 {
+	return map[string]uint64{
+		"kent_collector_write_failures_total": c.writeFailures.Load(),
+	}
+}
 
-			if len(c.writers) == 0 {
-				continue
+// drain forwards what the fetchers send while they shut down and what is
+// left in the channel afterwards.
+func (c *Collector) drain(ctx context.Context, events chan *domain.Event, stopped <-chan struct{}) {
+	forwarded := 0
+	for {
+		select {
+		case ev := <-events:
+			c.forward(ctx, ev, true)
+			forwarded++
+		case <-stopped:
+			for len(events) > 0 {
+				c.forward(ctx, <-events, true)
+				forwarded++
 			}
+			c.logger.Info(ctx, "usecase:collector: drained pending events", "count", forwarded)
+			return
+		}
+	}
+}
 
-			entries := []*domain.LogEntry{logEntry}
-			for _, w := range c.writers {
-				if w == nil {
-					continue
-				}
-				if err := w.Write(ctx, entries); err != nil {
-					c.logger.Error(ctx, "usecase:collector: failed to write log entry", "error", err)
-				}
-			}
+// forward writes one event. shutdown is set while draining, when a failed
+// write keeps the event's checkpoint position so it is replayed on restart.
+func (c *Collector) forward(ctx context.Context, ev *domain.Event, shutdown bool) {
+	// events that are not written still count as handled, otherwise they
+	// would hold the checkpoint back
+	if c.dedup.Duplicate(ev) {
+		c.logger.Debug(ctx, "usecase:collector: dropping duplicate event",
+			"uid", ev.UID(),
+			"count", ev.Count(),
+		)
+		ev.Delivered()
+		return
+	}
+
+	logEntry, err := convertEventToLogEntry(ev)
+	if err != nil {
+		c.logger.Error(ctx, "failed to convert event to log entry", "error", err)
+		ev.Delivered()
+		return
+	}
+	logEntry.SetDeliveryHook(ev.Delivered)
 
+	for _, e := range c.enrichers {
+		e.Enrich(ctx, ev, logEntry.Fields())
+	}
+
+	if len(c.writers) == 0 {
+		ev.Delivered()
+		return
+	}
+
+	entries := []*domain.LogEntry{logEntry}
+	accepted := false
+	var lastErr error
+	for _, w := range c.writers {
+		if w == nil {
+			continue
+		}
+		if err := w.Write(ctx, entries); err != nil {
+			c.writeFailures.Add(1)
+			c.logger.Error(ctx, "usecase:collector: failed to write log entry", "error", err)
+			lastErr = err
+			continue
 		}
+		accepted = true
 	}
+	if accepted || lastErr == nil {
+		return
+	}
+
+	// no writer took the entry, so its delivery hook would never run and
+	// the checkpoint would stop at this event for good
+	if shutdown {
+		c.logger.Warn(ctx, "usecase:collector: checkpoint held at unwritten event, it is replayed on restart",
+			"uid", ev.UID(),
+			"error", lastErr,
+		)
+		return
+	}
+	c.logger.Warn(ctx, "usecase:collector: event lost, moving the checkpoint past it",
+		"uid", ev.UID(),
+		"error", lastErr,
+	)
+	ev.Delivered()
 }
 
 func convertEventToLogEntry(e *domain.Event) (*domain.LogEntry, error) {
@@ -99,19 +200,56 @@ func convertEventToLogEntry(e *domain.Event) (*domain.LogEntry, error) {
 		"event.count":   fmt.Sprintf("%d", e.Count()),
 	}
 
+	if e.ClusterID() != "" {
+		fields["clusterID"] = e.ClusterID()
+	}
+	if e.WatchType() != "" {
+		fields["event.watch_type"] = e.WatchType()
+	}
+
+	obj := e.Object()
+	setIfNotEmpty(fields, "k8s.uid", obj.UID)
+	setIfNotEmpty(fields, "k8s.api_version", obj.APIVersion)
+	setIfNotEmpty(fields, "k8s.field_path", obj.FieldPath)
+
+	details := e.Details()
+	setIfNotEmpty(fields, "event.action", details.Action)
+	setIfNotEmpty(fields, "event.reporting_instance", details.ReportingInstance)
+	setIfNotEmpty(fields, "event.source_host", details.SourceHost)
+	if details.SeriesLastObservedTime != nil {
+		fields["event.series_last_observed_time"] = details.SeriesLastObservedTime.UTC().Format(time.RFC3339Nano)
+	}
+	if rel := details.Related; rel != nil {
+		setIfNotEmpty(fields, "k8s.related.kind", rel.Kind)
+		setIfNotEmpty(fields, "k8s.related.name", rel.Name)
+		setIfNotEmpty(fields, "k8s.related.namespace", rel.Namespace)
+		setIfNotEmpty(fields, "k8s.related.uid", rel.UID)
+		setIfNotEmpty(fields, "k8s.related.api_version", rel.APIVersion)
+		setIfNotEmpty(fields, "k8s.related.field_path", rel.FieldPath)
+	}
+
+	for k, v := range e.Attributes() {
+		fields[k] = v
+	}
+
 	level := mapEventTypeToLevel(e.Type())
-	logType := "event" //Hardcoded type. In future may be several types.
 
 	return domain.NewLogEntry(
 		e.EventTime(),
 		level,
-		logType,
+		e.LogType(),
 		e.Message(),
 		fields,
 	)
 
 }
 
+func setIfNotEmpty(fields map[string]string, key, value string) {
+	if value != "" {
+		fields[key] = value
+	}
+}
+
 func mapEventTypeToLevel(eventType string) string {
 	// See: https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.30/#event-v1-core
 
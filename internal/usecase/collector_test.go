@@ -0,0 +1,76 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package usecase
+
+import (
+	"context"
+	"errors"
+	"event_exporter/internal/domain"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(context.Context, string, ...any) {}
+func (nopLogger) Info(context.Context, string, ...any)  {}
+func (nopLogger) Warn(context.Context, string, ...any)  {}
+func (nopLogger) Error(context.Context, string, ...any) {}
+
+// writerFunc confirms every entry it accepts, like a writer whose batch was
+// stored right away.
+type writerFunc func() error
+
+func (f writerFunc) Write(_ context.Context, logs []*domain.LogEntry) error {
+	if err := f(); err != nil {
+		return err
+	}
+	for _, l := range logs {
+		l.Delivered()
+	}
+	return nil
+}
+
+func TestCollectorReleasesCheckpointOnFailedWrite(t *testing.T) {
+	failing := writerFunc(func() error { return errors.New("writer stopped") })
+	working := writerFunc(func() error { return nil })
+
+	tests := []struct {
+		name          string
+		writers       []LogWriter
+		shutdown      bool
+		wantDelivered bool
+		wantFailures  uint64
+	}{
+		{name: "written", writers: []LogWriter{working}, wantDelivered: true},
+		{name: "failed while running", writers: []LogWriter{failing}, wantDelivered: true, wantFailures: 1},
+		{name: "failed during shutdown", writers: []LogWriter{failing}, shutdown: true, wantFailures: 1},
+		{name: "another writer took it", writers: []LogWriter{failing, working}, shutdown: true, wantDelivered: true, wantFailures: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := domain.NewEvent("uid-1", "web", "apps", "Started", "started", "Normal",
+				domain.ObjectRef{Kind: "Pod", Name: "web", Namespace: "apps"},
+				"kubelet", time.Now(), nil, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			delivered := 0
+			ev.SetDeliveryHook(func() { delivered++ })
+
+			c := NewCollector(nil, tt.writers, nil, nil, nopLogger{})
+			c.forward(context.Background(), ev, tt.shutdown)
+
+			if got := delivered > 0; got != tt.wantDelivered {
+				t.Fatalf("delivered = %v, want %v", got, tt.wantDelivered)
+			}
+			if got := c.Counters()["kent_collector_write_failures_total"]; got != tt.wantFailures {
+				t.Fatalf("write failures = %d, want %d", got, tt.wantFailures)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package usecase
+
+import (
+	"container/list"
+	"event_exporter/internal/domain"
+	"sync"
+	"time"
+)
+
+// Deduplicator remembers the last (count, lastTimestamp) seen per event UID in
+// a bounded LRU and reports notifications that carry no new occurrence, such
+// as the copies re-delivered after a relist.
+type Deduplicator struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	items map[string]*list.Element
+	order *list.List
+}
+
+type dedupEntry struct {
+	uid    string
+	count  int32
+	last   time.Time
+	seenAt time.Time
+}
+
+func NewDeduplicator(size int, ttl time.Duration) *Deduplicator {
+	if size <= 0 {
+		size = 10000
+	}
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	return &Deduplicator{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[string]*list.Element, size),
+		order: list.New(),
+	}
+}
+
+// Duplicate reports whether ev is a repeat of an already forwarded occurrence.
+// Deletions are never duplicates; they only forget the UID.
+// A nil Deduplicator never reports duplicates.
+func (d *Deduplicator) Duplicate(ev *domain.Event) bool {
+	if d == nil {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if ev.WatchType() == "DELETED" {
+		if el, ok := d.items[ev.UID()]; ok {
+			d.order.Remove(el)
+			delete(d.items, ev.UID())
+		}
+		return false
+	}
+
+	now := time.Now()
+	last := ev.EventTime()
+	if lt := ev.LastTimestamp(); lt != nil {
+		last = *lt
+	}
+
+	if el, ok := d.items[ev.UID()]; ok {
+		entry := el.Value.(*dedupEntry)
+		d.order.MoveToFront(el)
+
+		fresh := now.Sub(entry.seenAt) <= d.ttl
+		entry.seenAt = now
+
+		if fresh && ev.Count() <= entry.count && !last.After(entry.last) {
+			return true
+		}
+
+		entry.count = ev.Count()
+		entry.last = last
+		return false
+	}
+
+	d.items[ev.UID()] = d.order.PushFront(&dedupEntry{
+		uid:    ev.UID(),
+		count:  ev.Count(),
+		last:   last,
+		seenAt: now,
+	})
+
+	for d.order.Len() > d.size {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.items, oldest.Value.(*dedupEntry).uid)
+	}
+
+	return false
+}
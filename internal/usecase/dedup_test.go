@@ -0,0 +1,112 @@
+// Copyright 2025 Stas Levchenko
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//     http://www.apache.org/licenses/LICENSE-2.0
+
+package usecase
+
+import (
+	"event_exporter/internal/domain"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDeduplicatorDuplicate(t *testing.T) {
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	type notification struct {
+		uid       string
+		count     int32
+		last      time.Time
+		watchType string
+	}
+	seen := func(uid string, count int32, last time.Time) notification {
+		return notification{uid: uid, count: count, last: last, watchType: "ADDED"}
+	}
+	deleted := func(uid string) notification {
+		return notification{uid: uid, count: 1, last: base, watchType: "DELETED"}
+	}
+
+	tests := []struct {
+		name   string
+		size   int
+		events []notification
+		want   []bool
+	}{
+		{
+			name:   "first occurrence",
+			events: []notification{seen("a", 1, base)},
+			want:   []bool{false},
+		},
+		{
+			name:   "redelivered copy",
+			events: []notification{seen("a", 1, base), seen("a", 1, base)},
+			want:   []bool{false, true},
+		},
+		{
+			name:   "count increased",
+			events: []notification{seen("a", 1, base), seen("a", 2, base.Add(time.Minute))},
+			want:   []bool{false, false},
+		},
+		{
+			name:   "newer timestamp with same count",
+			events: []notification{seen("a", 1, base), seen("a", 1, base.Add(time.Minute))},
+			want:   []bool{false, false},
+		},
+		{
+			name:   "older copy after newer occurrence",
+			events: []notification{seen("a", 2, base.Add(time.Minute)), seen("a", 1, base)},
+			want:   []bool{false, true},
+		},
+		{
+			name:   "different uids",
+			events: []notification{seen("a", 1, base), seen("b", 1, base)},
+			want:   []bool{false, false},
+		},
+		{
+			name:   "deletion forgets the uid",
+			events: []notification{seen("a", 1, base), deleted("a"), seen("a", 1, base)},
+			want:   []bool{false, false, false},
+		},
+		{
+			name:   "evicted by size",
+			size:   1,
+			events: []notification{seen("a", 1, base), seen("b", 1, base), seen("a", 1, base)},
+			want:   []bool{false, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDeduplicator(tt.size, time.Hour)
+			var got []bool
+			for _, n := range tt.events {
+				last := n.last
+				ev, err := domain.NewEvent(n.uid, "pod", "default", "Started", "msg", "Normal",
+					domain.ObjectRef{Kind: "Pod", Name: "pod"}, "kubelet", base, &last, n.count)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ev.SetWatchType(n.watchType)
+				got = append(got, d.Duplicate(ev))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("duplicates = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNilDeduplicator(t *testing.T) {
+	var d *Deduplicator
+	ev, err := domain.NewEvent("a", "pod", "default", "Started", "msg", "Normal",
+		domain.ObjectRef{Kind: "Pod", Name: "pod"}, "kubelet", time.Now(), nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Duplicate(ev) {
+		t.Fatal("nil deduplicator reported a duplicate")
+	}
+}